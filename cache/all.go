@@ -0,0 +1,29 @@
+package cache
+
+import "iter"
+
+// All returns an iterator over a snapshot of the unexpired entries, so the
+// loop body runs without holding the lock and may use the cache.
+// Iterating doesn't count as hits.
+func (c *Instance) All() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		c.lock.RLock()
+		keys := make([]string, 0, len(c.storage))
+		values := make([]interface{}, 0, len(c.storage))
+		now := nowNano()
+		for key, e := range c.storage {
+			if e.expired(now) {
+				continue
+			}
+			keys = append(keys, key)
+			values = append(values, e.value)
+		}
+		c.lock.RUnlock()
+
+		for i, key := range keys {
+			if !yield(key, c.decoded(values[i])) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package cache
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMostAccessed(t *testing.T) {
+	c := New()
+	hits := map[string]int{"a": 3, "b": 5, "c": 3, "d": 0, "e": 1}
+	for key, n := range hits {
+		c.Put(key, key)
+		for i := 0; i < n; i++ {
+			if _, err := c.Get(key); err != nil {
+				t.Fatalf("Get(%q) failed: %s", key, err)
+			}
+		}
+	}
+
+	top := c.MostAccessed(4)
+	expected := []string{"b", "a", "c", "e"}
+	if len(top) != len(expected) {
+		t.Fatalf("expected %d keys, got %+v", len(expected), top)
+	}
+	for i, key := range expected {
+		if top[i].Key != key || top[i].Hits != uint64(hits[key]) {
+			t.Errorf("#%d: expected %s with %d hits, got %+v", i, key, hits[key], top[i])
+		}
+	}
+	if all := c.MostAccessed(100); len(all) != len(hits) {
+		t.Errorf("expected all %d keys, got %d", len(hits), len(all))
+	}
+}
+
+func TestReset(t *testing.T) {
+	c := New()
+	for _, key := range []string{"a", "b", "c"} {
+		c.Put(key, key)
+	}
+	c.Reset()
+	if n := len(c.storage); n != 0 {
+		t.Errorf("expected no entries after Reset, got %d", n)
+	}
+	if _, err := c.Get("a"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after Reset, got %v", err)
+	}
+	c.Put("a", 1)
+	if v, err := c.Get("a"); err != nil || v != 1 {
+		t.Errorf("cache isn't reusable after Reset: %v, %v", v, err)
+	}
+
+	sink := &recordingSink{}
+	c.OnEvict(sink.put)
+	c.Put("b", 2)
+	c.Reset()
+	if w := fmt.Sprint(sink.sorted()); w != "[a=1 b=2]" {
+		t.Errorf("unexpected evictions by Reset: %s", w)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := New()
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Delete("a")
+	c.Delete("missing")
+	if _, err := c.Get("a"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for a deleted key, got %v", err)
+	}
+	c.Put("c", 3)
+	if v, err := c.Get("b"); err != nil || v != 2 {
+		t.Errorf("unexpected value by b: %v, %v", v, err)
+	}
+	if v, err := c.Get("c"); err != nil || v != 3 {
+		t.Errorf("a recycled entry has a stale value: %v, %v", v, err)
+	}
+}
+
+func TestAll(t *testing.T) {
+	c := New()
+	for i := 0; i < 10; i++ {
+		c.Put(fmt.Sprint(i), i)
+	}
+	seen := map[string]interface{}{}
+	for key, value := range c.All() {
+		c.Put("added", 0) // the lock isn't held here
+		if key != "added" {
+			seen[key] = value
+		}
+	}
+	if len(seen) != 10 {
+		t.Errorf("expected 10 entries, got %d", len(seen))
+	}
+	for key, value := range seen {
+		if key != fmt.Sprint(value) {
+			t.Errorf("unexpected value by %s: %v", key, value)
+		}
+	}
+
+	n := 0
+	for range c.All() {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("break didn't stop the loop, %d iterations", n)
+	}
+}
+
+type recordingSink struct {
+	lock   sync.Mutex
+	writes []string
+}
+
+func (s *recordingSink) put(key string, value interface{}) {
+	s.lock.Lock()
+	s.writes = append(s.writes, fmt.Sprint(key, "=", value))
+	s.lock.Unlock()
+}
+
+func (s *recordingSink) sorted() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res := append([]string(nil), s.writes...)
+	sort.Strings(res)
+	return res
+}
+
+func TestWriteBehindCoalescing(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewWriteBehind(10, time.Hour, sink.put)
+	defer c.Close()
+	c.Put("a", 1)
+	c.Put("b", 1)
+	c.Put("a", 2)
+	c.Put("a", 3)
+	if w := sink.sorted(); len(w) != 0 {
+		t.Fatalf("writes reached the sink before a flush: %v", w)
+	}
+	c.Flush()
+	if w := fmt.Sprint(sink.sorted()); w != "[a=3 b=1]" {
+		t.Errorf("unexpected writes after Flush: %s", w)
+	}
+	c.Flush()
+	if n := len(sink.sorted()); n != 2 {
+		t.Errorf("an empty Flush wrote to the sink, %d writes", n)
+	}
+}
+
+func TestWriteBehindBackpressure(t *testing.T) {
+	sink := &recordingSink{}
+	c := NewWriteBehind(2, time.Hour, sink.put)
+	c.Put("a", 1)
+	c.Put("b", 1)
+	c.Put("b", 2) // pending keys can be overwritten when full
+	c.Put("c", 1) // waits for the background flush
+	c.Flush()
+	if w := fmt.Sprint(sink.sorted()); w != "[a=1 b=2 c=1]" {
+		t.Errorf("unexpected writes: %s", w)
+	}
+	c.Put("d", 1)
+	c.Close()
+	c.Put("e", 1)
+	c.Put("f", 1)
+	c.Put("g", 1) // flushed inline after Close
+	if w := fmt.Sprint(sink.sorted()); w != "[a=1 b=2 c=1 d=1 e=1 f=1]" {
+		t.Errorf("unexpected writes after Close: %s", w)
+	}
+	if v, err := c.Get("g"); err != nil || v != 1 {
+		t.Errorf("the cache lost a write-behind value: %v, %v", v, err)
+	}
+}
+
+func TestWriteBehindSlowSink(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	c := NewWriteBehind(1, time.Hour, func(string, interface{}) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	})
+	c.Put("a", 1)
+	<-started     // the sink is stuck on a
+	c.Put("b", 1) // fills the buffer
+	put := make(chan struct{}, 2)
+	go func() {
+		c.Put("c", 1) // waits for the sink
+		put <- struct{}{}
+	}()
+	go func() {
+		c.Replace("a", 2) // so does Replace
+		put <- struct{}{}
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	read := make(chan struct{})
+	go func() {
+		c.Get("a")
+		c.Len()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatalf("readers were blocked by the sink")
+	}
+	select {
+	case <-put:
+		t.Fatalf("a write didn't wait for room in the buffer")
+	default:
+	}
+	close(release)
+	<-put
+	<-put
+	c.Close()
+	if _, ok := c.Replace("missing", 1); ok {
+		t.Errorf("Replace inserted a missing key")
+	}
+}
+
+// fakeClock makes the expiry checks use the returned clock until the test ends
+func fakeClock(t *testing.T) *int64 {
+	now := time.Now().UnixNano()
+	old := nowNano
+	nowNano = func() int64 { return now }
+	t.Cleanup(func() { nowNano = old })
+	return &now
+}
+
+func TestPutWithTTL(t *testing.T) {
+	now := fakeClock(t)
+	c := New()
+	c.PutWithTTL("short", 1, time.Second)
+	c.PutWithTTL("long", 2, time.Hour)
+	c.PutWithTTL("forever", 3, 0)
+	c.Put("plain", 4)
+
+	*now += int64(time.Second) - 1
+	if v, err := c.Get("short"); err != nil || v != 1 {
+		t.Errorf("key expired early: %v, %v", v, err)
+	}
+	*now++
+	if _, err := c.Get("short"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for an expired key, got %v", err)
+	}
+	if _, ok := c.storage["short"]; ok {
+		t.Errorf("Get didn't evict the expired key")
+	}
+	for key, value := range map[string]int{"long": 2, "forever": 3, "plain": 4} {
+		if v, err := c.Get(key); err != nil || v != value {
+			t.Errorf("unexpected value by %s: %v, %v", key, v, err)
+		}
+	}
+
+	*now += int64(time.Hour)
+	c.Put("long", 5) // putting again drops the TTL
+	if v, err := c.Get("long"); err != nil || v != 5 {
+		t.Errorf("re-put key is missing: %v, %v", v, err)
+	}
+	c.PutWithTTL("forever", 6, time.Minute)
+	*now += int64(time.Minute)
+	for key := range c.All() {
+		if key == "forever" {
+			t.Errorf("All yielded an expired key")
+		}
+	}
+	if top := c.MostAccessed(10); len(top) != 2 {
+		t.Errorf("expected 2 unexpired keys, got %+v", top)
+	}
+}
+
+func TestExpiryRace(t *testing.T) {
+	c := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprint(j % 10)
+				if i%2 == 0 {
+					c.PutWithTTL(key, j, time.Duration(j%3))
+				} else {
+					c.Get(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestReplace(t *testing.T) {
+	now := fakeClock(t)
+	c := New()
+	c.Put("a", 1)
+	if old, ok := c.Replace("a", 2); !ok || old != 1 {
+		t.Errorf("Replace returned %v, %v", old, ok)
+	}
+	if v, err := c.Get("a"); err != nil || v != 2 {
+		t.Errorf("unexpected value after Replace: %v, %v", v, err)
+	}
+	if old, ok := c.Replace("missing", 1); ok || old != nil {
+		t.Errorf("Replace of a missing key returned %v, %v", old, ok)
+	}
+	if _, err := c.Get("missing"); err != ErrNotFound {
+		t.Errorf("Replace inserted a missing key")
+	}
+
+	c.PutWithTTL("ttl", 1, time.Second)
+	c.Replace("ttl", 2)
+	*now += int64(time.Second)
+	if old, ok := c.Replace("ttl", 3); ok {
+		t.Errorf("Replace of an expired key returned %v, %v", old, ok)
+	}
+}
+
+func TestLenKeys(t *testing.T) {
+	now := fakeClock(t)
+	c := New()
+	if n, keys := c.Len(), c.Keys(); n != 0 || keys == nil || len(keys) != 0 {
+		t.Errorf("empty cache: Len %d, Keys %#v", n, keys)
+	}
+	c.Put("b", 1)
+	c.Put("a", 1)
+	c.PutWithTTL("expiring", 1, time.Second)
+	if n := c.Len(); n != 3 {
+		t.Errorf("expected Len 3, got %d", n)
+	}
+	*now += int64(time.Second)
+	keys := c.Keys()
+	sort.Strings(keys)
+	if n := c.Len(); n != 2 || fmt.Sprint(keys) != "[a b]" {
+		t.Errorf("after expiry: Len %d, Keys %v", n, keys)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	now := fakeClock(t)
+	c := New()
+	const keys = 1000
+	for i := 0; i < keys; i++ {
+		c.PutWithTTL(fmt.Sprint(i), i, time.Second)
+	}
+	c.Put("forever", -1)
+	evicted := map[string]interface{}{}
+	c.OnEvict(func(key string, value interface{}) {
+		evicted[key] = value
+		c.Len() // the lock isn't held
+	})
+	if n := c.sweep(); n != 0 {
+		t.Fatalf("swept %d unexpired entries", n)
+	}
+
+	*now += int64(time.Second)
+	c.PutWithTTL("0", 0, time.Second) // put again, not expired anymore
+	// all the expired entries go in a single write-locked pass
+	if n := c.sweep(); n != keys-1 {
+		t.Errorf("expected %d entries swept, got %d", keys-1, n)
+	}
+	if len(evicted) != keys-1 || evicted["42"] != 42 {
+		t.Errorf("OnEvict got %d entries, 42 is %v", len(evicted), evicted["42"])
+	}
+	if n := len(c.storage); n != 2 {
+		t.Errorf("expected 2 entries left, got %d", n)
+	}
+
+	*now += int64(time.Second)
+	c.Get("0") // evicted lazily
+	if _, ok := evicted["0"]; !ok {
+		t.Errorf("OnEvict wasn't called by Get")
+	}
+}
+
+func TestStartJanitor(t *testing.T) {
+	c := New()
+	c.PutWithTTL("a", 1, time.Nanosecond)
+	evicted := make(chan string, 1)
+	c.OnEvict(func(key string, value interface{}) { evicted <- key })
+	stop := c.StartJanitor(time.Millisecond)
+	defer stop()
+	select {
+	case key := <-evicted:
+		if key != "a" {
+			t.Errorf("unexpected key evicted: %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the janitor didn't evict the expired key")
+	}
+	stop()
+}
+
+type flateCodec struct{}
+
+func (flateCodec) Compress(src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	return buf.Bytes(), err
+}
+
+func (flateCodec) Decompress(src []byte) ([]byte, error) {
+	return io.ReadAll(flate.NewReader(bytes.NewReader(src)))
+}
+
+func TestNewCompressed(t *testing.T) {
+	c := NewCompressed(flateCodec{}, 64)
+	small := []byte("small")
+	large := bytes.Repeat([]byte("large "), 100)
+	c.Put("small", small)
+	c.Put("large", large)
+	c.Put("other", "not bytes")
+
+	if _, ok := c.storage["small"].value.([]byte); !ok {
+		t.Errorf("a small value is stored as %T", c.storage["small"].value)
+	}
+	if z, ok := c.storage["large"].value.(compressed); !ok || len(z) >= len(large) {
+		t.Errorf("a large value isn't stored compressed")
+	}
+	for key, want := range map[string][]byte{"small": small, "large": large} {
+		if v, err := c.Get(key); err != nil || !bytes.Equal(v.([]byte), want) {
+			t.Errorf("%s didn't round-trip: %v", key, err)
+		}
+	}
+	if v, err := c.Get("other"); err != nil || v != "not bytes" {
+		t.Errorf("a non-[]byte value didn't round-trip: %v, %v", v, err)
+	}
+	for key, v := range c.All() {
+		if b, ok := v.([]byte); ok && key == "large" && !bytes.Equal(b, large) {
+			t.Errorf("All yielded a compressed value")
+		}
+	}
+	if old, _ := c.Replace("large", small); !bytes.Equal(old.([]byte), large) {
+		t.Errorf("Replace returned a compressed value")
+	}
+}
+
+func TestPutLazy(t *testing.T) {
+	c := New()
+	var calls int32
+	c.PutLazy("key", func() interface{} {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "value"
+	})
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("init was called %d times before a Get", n)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.Get("key"); err != nil || v != "value" {
+				t.Errorf("Get returned %v, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := c.Get("key"); v != "value" {
+		t.Errorf("Get returned %v", v)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("init was called %d times", n)
+	}
+
+	sink := &recordingSink{}
+	wb := NewWriteBehind(10, time.Hour, sink.put)
+	defer wb.Close()
+	wb.Put("key", "old")
+	if err := wb.PutLazy("key", func() interface{} { return "new" }); err != ErrLazyWriteBehind {
+		t.Errorf("expected ErrLazyWriteBehind, got %v", err)
+	}
+	if v, err := wb.Get("key"); err != nil || v != "old" {
+		t.Errorf("a rejected PutLazy changed the value to %v, %v", v, err)
+	}
+	wb.Flush()
+	if w := fmt.Sprint(sink.sorted()); w != "[key=old]" {
+		t.Errorf("unexpected writes: %s", w)
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	c := New()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keys[i&1023]
+		c.Put(key, nil)
+		c.Delete(key)
+	}
+}
+
+func BenchmarkBulkLoad_65536(b *testing.B)             { benchmarkBulkLoad(b, 65536, false) }
+func BenchmarkBulkLoadWithCapacity_65536(b *testing.B) { benchmarkBulkLoad(b, 65536, true) }
+
+func benchmarkBulkLoad(b *testing.B, n int, presize bool) {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var c *Instance
+		if presize {
+			c = NewWithCapacity(n)
+		} else {
+			c = New()
+		}
+		for _, key := range keys {
+			c.Put(key, nil)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+// Codec compresses the values of a cache created with NewCompressed
+type Codec interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+}
+
+// compressed is a []byte value stored compressed
+type compressed []byte
+
+// NewCompressed creates an Instance that stores the []byte values of at
+// least minSize bytes compressed by codec, and decompresses them on Get.
+// Values that fail to compress or don't get smaller are stored as is.
+// Get returns the error of a failed decompression, the other methods
+// returning values pass nil for them.
+func NewCompressed(codec Codec, minSize int) *Instance {
+	c := New()
+	c.codec = codec
+	c.minCompress = minSize
+	return c
+}
+
+// encode returns the value to store for value
+func (c *Instance) encode(value interface{}) interface{} {
+	b, ok := value.([]byte)
+	if c.codec == nil || !ok || len(b) < c.minCompress {
+		return value
+	}
+	z, err := c.codec.Compress(b)
+	if err != nil || len(z) >= len(b) {
+		return value
+	}
+	return compressed(z)
+}
+
+// decode returns the value put for the stored value v
+func (c *Instance) decode(v interface{}) (interface{}, error) {
+	if l, ok := v.(*lazy); ok {
+		return l.get(), nil
+	}
+	z, ok := v.(compressed)
+	if !ok {
+		return v, nil
+	}
+	return c.codec.Decompress(z)
+}
+
+// decoded is decode for the methods that can't return an error
+func (c *Instance) decoded(v interface{}) interface{} {
+	v, err := c.decode(v)
+	if err != nil {
+		return nil
+	}
+	return v
+}
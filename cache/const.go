@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrNotFound error occurred in .Get() when key was not found
 	ErrNotFound = errors.New("Key not found")
+	// ErrLazyWriteBehind error occurred in .PutLazy() on a write-behind cache
+	ErrLazyWriteBehind = errors.New("Lazy values can't be written behind")
 )
@@ -0,0 +1,11 @@
+package cache
+
+// Delete a key from the cache
+func (c *Instance) Delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.storage[key]; ok {
+		delete(c.storage, key)
+		e.release()
+	}
+}
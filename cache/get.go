@@ -1,11 +1,20 @@
 package cache
 
+import "sync/atomic"
+
 // Get a key from the cache
 func (c *Instance) Get(key string) (interface{}, error) {
 	c.lock.RLock()
-	defer c.lock.RUnlock()
-	if v, ok := c.storage[key]; ok {
-		return v, nil
+	e, ok := c.storage[key]
+	if ok && !e.expired(nowNano()) {
+		atomic.AddUint64(&e.hits, 1)
+		v := e.value
+		c.lock.RUnlock()
+		return c.decode(v)
+	}
+	c.lock.RUnlock()
+	if ok {
+		c.evict(key)
 	}
 	return nil, ErrNotFound
 }
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// MostAccessed returns up to n keys with the most Get hits, most hit first.
+// Keys with the same number of hits are sorted by key.
+// Putting a key again resets its hits. Expired keys are skipped.
+func (c *Instance) MostAccessed(n int) []struct {
+	Key  string
+	Hits uint64
+} {
+	c.lock.RLock()
+	top := make([]struct {
+		Key  string
+		Hits uint64
+	}, 0, len(c.storage))
+	now := nowNano()
+	for key, e := range c.storage {
+		if e.expired(now) {
+			continue
+		}
+		top = append(top, struct {
+			Key  string
+			Hits uint64
+		}{key, atomic.LoadUint64(&e.hits)})
+	}
+	c.lock.RUnlock()
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Hits != top[j].Hits {
+			return top[i].Hits > top[j].Hits
+		}
+		return top[i].Key < top[j].Key
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// StartJanitor removes the expired entries every interval until stop
+// is called, so keys that are never read again don't stay in memory.
+func (c *Instance) StartJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				c.sweep()
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// sweep removes the expired entries and returns their number.
+// It finds them under the read lock and takes the write lock once
+// to remove them all, so readers are only blocked by the deletes.
+func (c *Instance) sweep() int {
+	now := nowNano()
+	c.lock.RLock()
+	var expired []string
+	for key, e := range c.storage {
+		if e.expired(now) {
+			expired = append(expired, key)
+		}
+	}
+	c.lock.RUnlock()
+	if len(expired) == 0 {
+		return 0
+	}
+
+	values := make([]interface{}, 0, len(expired))
+	c.lock.Lock()
+	n := 0
+	for _, key := range expired {
+		// the key may have been put again since the scan
+		if e, ok := c.storage[key]; ok && e.expired(now) {
+			expired[n] = key
+			values = append(values, e.value)
+			n++
+			delete(c.storage, key)
+			e.release()
+		}
+	}
+	onEvict := c.onEvict
+	c.lock.Unlock()
+
+	if onEvict != nil {
+		for i, value := range values {
+			onEvict(expired[i], c.decoded(value))
+		}
+	}
+	return n
+}
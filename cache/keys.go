@@ -0,0 +1,15 @@
+package cache
+
+// Keys returns a snapshot of the unexpired keys in no particular order
+func (c *Instance) Keys() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	keys := make([]string, 0, len(c.storage))
+	now := nowNano()
+	for key, e := range c.storage {
+		if !e.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,30 @@
+package cache
+
+import "sync"
+
+// lazy is a value computed by init on its first access
+type lazy struct {
+	once  sync.Once
+	init  func() interface{}
+	value interface{}
+}
+
+// PutLazy puts a value in a key that is computed by calling init when it's
+// first read, by Get or any other method returning values. init is called
+// once, concurrent first reads wait for it, and later reads return the
+// computed value. It returns ErrLazyWriteBehind and puts nothing on a cache
+// created with NewWriteBehind, whose sink would miss the value.
+func (c *Instance) PutLazy(key string, init func() interface{}) error {
+	if c.wb != nil {
+		return ErrLazyWriteBehind
+	}
+	return c.put(key, &lazy{init: init}, 0)
+}
+
+func (l *lazy) get() interface{} {
+	l.once.Do(func() {
+		l.value = l.init()
+		l.init = nil
+	})
+	return l.value
+}
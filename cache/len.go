@@ -0,0 +1,16 @@
+package cache
+
+// Len returns the number of unexpired keys.
+// It checks the expiry of every entry, so it's O(n).
+func (c *Instance) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	n := 0
+	now := nowNano()
+	for _, e := range c.storage {
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
@@ -4,8 +4,14 @@ import "sync"
 
 // New Instance
 func New() *Instance {
+	return NewWithCapacity(0)
+}
+
+// NewWithCapacity creates an Instance with room for n keys,
+// so filling it up to n keys doesn't grow its storage
+func NewWithCapacity(n int) *Instance {
 	return &Instance{
-		storage: make(map[string]interface{}),
+		storage: make(map[string]*entry, n),
 		lock:    sync.RWMutex{},
 	}
 }
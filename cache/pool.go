@@ -0,0 +1,24 @@
+package cache
+
+import "sync"
+
+// entryPool recycles the entries of deleted keys,
+// so churny caches don't allocate an entry per Put.
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return new(entry)
+	},
+}
+
+func newEntry(value interface{}) *entry {
+	e := entryPool.Get().(*entry)
+	e.value = value
+	return e
+}
+
+// release resets e and puts it back to the pool.
+// e must not be reachable from the cache anymore.
+func (e *entry) release() {
+	*e = entry{}
+	entryPool.Put(e)
+}
@@ -2,8 +2,25 @@ package cache
 
 // Put the value in a key
 func (c *Instance) Put(key string, value interface{}) error {
+	return c.put(key, value, 0)
+}
+
+func (c *Instance) put(key string, value interface{}, expires int64) error {
+	stored := c.encode(value)
+	if c.wb != nil {
+		c.wb.reserve(key)
+	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.storage[key] = value
+	if c.wb != nil {
+		c.wb.insert(key, value)
+	}
+	if e, ok := c.storage[key]; ok {
+		*e = entry{value: stored, expires: expires}
+		return nil
+	}
+	e := newEntry(stored)
+	e.expires = expires
+	c.storage[key] = e
 	return nil
 }
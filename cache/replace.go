@@ -0,0 +1,25 @@
+package cache
+
+// Replace the value of an existing key, returning the previous one.
+// Missing and expired keys aren't inserted. The key keeps its expiry.
+func (c *Instance) Replace(key string, value interface{}) (old interface{}, existed bool) {
+	stored := c.encode(value)
+	if c.wb != nil {
+		c.wb.reserve(key)
+	}
+	c.lock.Lock()
+	e, ok := c.storage[key]
+	if !ok || e.expired(nowNano()) {
+		c.lock.Unlock()
+		if c.wb != nil {
+			c.wb.cancel()
+		}
+		return nil, false
+	}
+	if c.wb != nil {
+		c.wb.insert(key, value)
+	}
+	old, e.value = e.value, stored
+	c.lock.Unlock()
+	return c.decoded(old), true
+}
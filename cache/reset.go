@@ -0,0 +1,23 @@
+package cache
+
+// Reset removes all keys but keeps the allocated storage for reuse.
+// The OnEvict callback is called for each removed entry after that.
+func (c *Instance) Reset() {
+	var keys []string
+	var values []interface{}
+	c.lock.Lock()
+	onEvict := c.onEvict
+	for key, e := range c.storage {
+		if onEvict != nil {
+			keys = append(keys, key)
+			values = append(values, e.value)
+		}
+		delete(c.storage, key)
+		e.release()
+	}
+	c.lock.Unlock()
+
+	for i, value := range values {
+		onEvict(keys[i], c.decoded(value))
+	}
+}
@@ -0,0 +1,49 @@
+package cache
+
+import "time"
+
+// nowNano is the clock of the expiry checks, replaced in tests
+var nowNano = func() int64 { return time.Now().UnixNano() }
+
+// PutWithTTL puts the value in a key for ttl. After that the key is reported
+// missing and its entry is removed on the next Get. A non-positive ttl
+// means the entry doesn't expire, like with Put.
+func (c *Instance) PutWithTTL(key string, value interface{}, ttl time.Duration) error {
+	var expires int64
+	if ttl > 0 {
+		expires = nowNano() + int64(ttl)
+	}
+	return c.put(key, value, expires)
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expires != 0 && now >= e.expires
+}
+
+// OnEvict sets a callback called with the key and the value of each
+// expired entry removed from the cache, by Get or by the janitor, and
+// of each entry removed by Reset. It's called without holding the lock,
+// so it may use the cache.
+func (c *Instance) OnEvict(fn func(key string, value interface{})) {
+	c.lock.Lock()
+	c.onEvict = fn
+	c.lock.Unlock()
+}
+
+// evict removes the key if it's expired. The caller must not hold the lock.
+func (c *Instance) evict(key string) {
+	c.lock.Lock()
+	// the key may have been put again since its expiry was seen
+	e, ok := c.storage[key]
+	if !ok || !e.expired(nowNano()) {
+		c.lock.Unlock()
+		return
+	}
+	value, onEvict := e.value, c.onEvict
+	delete(c.storage, key)
+	e.release()
+	c.lock.Unlock()
+	if onEvict != nil {
+		onEvict(key, c.decoded(value))
+	}
+}
@@ -8,7 +8,19 @@ import (
 
 // Instance represents a cache instance
 type Instance struct {
-	storage map[string]interface{}
+	storage map[string]*entry
 	nocopy  nocopy.NoCopy
 	lock    sync.RWMutex
+	wb      *writeBehind // see NewWriteBehind
+	onEvict func(key string, value interface{})
+
+	codec       Codec // see NewCompressed
+	minCompress int
+}
+
+// entry is a cached value with its access stats
+type entry struct {
+	value   interface{}
+	hits    uint64 // updated atomically, since Get holds the read lock only
+	expires int64  // UnixNano, 0 if the entry doesn't expire
 }
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// writeBehind buffers the Puts of a cache for its sink.
+// Repeated Puts of a key are coalesced until the next flush.
+type writeBehind struct {
+	sink func(key string, value interface{})
+	size int
+
+	lock     sync.Mutex
+	space    *sync.Cond // broadcast when pending is flushed, or written to after Close
+	pending  map[string]interface{}
+	reserved int // # of writes between reserve and insert
+	stopped  bool
+
+	// flushing serializes the flushes, so the sink
+	// gets the writes of a key in order
+	flushing sync.Mutex
+
+	kick chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewWriteBehind creates an Instance that passes the values put into it
+// to sink asynchronously, at least every interval and whenever size keys
+// are pending. Only the last value of a key put between two flushes
+// reaches the sink. Put blocks while size other keys are pending.
+// Deletes aren't passed to the sink. The sink must not use the cache.
+// Call Close to stop the flushing.
+func NewWriteBehind(size int, interval time.Duration, sink func(key string, value interface{})) *Instance {
+	if size < 1 {
+		size = 1
+	}
+	wb := &writeBehind{
+		sink:    sink,
+		size:    size,
+		pending: make(map[string]interface{}, size),
+		kick:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	wb.space = sync.NewCond(&wb.lock)
+	go wb.loop(interval)
+
+	c := New()
+	c.wb = wb
+	return c
+}
+
+func (wb *writeBehind) loop(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-wb.kick:
+		case <-t.C:
+		case <-wb.done:
+			return
+		}
+		wb.flush()
+	}
+}
+
+// reserve waits for room in the buffer for a write of key, flushing it
+// itself after Close. It must be called without holding the cache lock,
+// so a slow sink doesn't block the readers, and be followed by insert
+// or cancel.
+func (wb *writeBehind) reserve(key string) {
+	wb.lock.Lock()
+	for len(wb.pending)+wb.reserved >= wb.size {
+		if _, ok := wb.pending[key]; ok {
+			break
+		}
+		if wb.stopped && len(wb.pending) > 0 {
+			wb.lock.Unlock()
+			wb.flush()
+			wb.lock.Lock()
+			continue
+		}
+		wb.wake()
+		wb.space.Wait()
+	}
+	wb.reserved++
+	wb.lock.Unlock()
+}
+
+// insert makes a reserved write pending. It doesn't block, so it's called
+// under the cache lock, which keeps the writes of a key in order.
+func (wb *writeBehind) insert(key string, value interface{}) {
+	wb.lock.Lock()
+	wb.reserved--
+	wb.pending[key] = value
+	if len(wb.pending) >= wb.size {
+		wb.wake()
+	}
+	if wb.stopped {
+		wb.space.Broadcast() // reserve may wait for a write to flush
+	}
+	wb.lock.Unlock()
+}
+
+// cancel releases a reservation that won't be inserted
+func (wb *writeBehind) cancel() {
+	wb.lock.Lock()
+	wb.reserved--
+	wb.space.Broadcast()
+	wb.lock.Unlock()
+}
+
+// wake asks the loop to flush, without waiting for it
+func (wb *writeBehind) wake() {
+	select {
+	case wb.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (wb *writeBehind) flush() {
+	wb.flushing.Lock()
+	defer wb.flushing.Unlock()
+
+	wb.lock.Lock()
+	batch := wb.pending
+	if len(batch) == 0 {
+		wb.lock.Unlock()
+		return
+	}
+	wb.pending = make(map[string]interface{}, wb.size)
+	wb.space.Broadcast()
+	wb.lock.Unlock()
+
+	for key, value := range batch {
+		wb.sink(key, value)
+	}
+}
+
+func (wb *writeBehind) stop() {
+	wb.once.Do(func() {
+		wb.lock.Lock()
+		wb.stopped = true
+		wb.lock.Unlock()
+		close(wb.done)
+	})
+	wb.flush()
+}
+
+// Flush passes the pending writes to the sink and returns when it's done.
+// It's a no-op for caches created without NewWriteBehind.
+func (c *Instance) Flush() {
+	if c.wb != nil {
+		c.wb.flush()
+	}
+}
+
+// Close stops the background flushing and flushes the pending writes.
+// Puts after Close are flushed synchronously once size keys are pending.
+func (c *Instance) Close() {
+	if c.wb != nil {
+		c.wb.stop()
+	}
+}
@@ -0,0 +1,20 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// BucketAllocator, if set, allocates all bucket arrays instead of
+// runtimer.Newarray. It must return zeroed memory for n values of type t
+// that is known to the garbage collector, e.g. memory obtained from
+// runtimer.Newarray or reflect.
+var BucketAllocator func(t *runtimer.Type, n int) unsafe.Pointer
+
+func newarray(t *runtimer.Type, n int) unsafe.Pointer {
+	if BucketAllocator != nil {
+		return BucketAllocator(t, n)
+	}
+	return runtimer.Newarray(t, n)
+}
@@ -0,0 +1,31 @@
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+func TestBucketAllocator(t *testing.T) {
+	allocs, buckets := 0, 0
+	BucketAllocator = func(t *runtimer.Type, n int) unsafe.Pointer {
+		allocs++
+		buckets += n
+		return runtimer.Newarray(t, n)
+	}
+	defer func() { BucketAllocator = nil }()
+
+	m := NewStrMap()
+	for i := 0; i < 1000; i++ {
+		m.Put(fmt.Sprint(i), "")
+	}
+	// 1 bucket allocated on the first Put, then a new array on each grow
+	if allocs < 2 {
+		t.Errorf("expected the allocator to be used on growth, got %d allocations", allocs)
+	}
+	if buckets < 1000/8 {
+		t.Errorf("expected at least %d buckets allocated, got %d", 1000/8, buckets)
+	}
+}
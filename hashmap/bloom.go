@@ -0,0 +1,68 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+const (
+	// bloomBitsPerKey and bloomHashes give a false positive rate of about 1%.
+	bloomBitsPerKey = 10
+	bloomHashes     = 7
+	bloomMinKeys    = 1024
+)
+
+// bloomFilter is a Bloom filter over string keys. It derives its bit
+// positions by double hashing with the map's own key hash function.
+type bloomFilter struct {
+	bits  []uint64
+	mask  uint64 // # of bits - 1
+	seed1 uintptr
+	seed2 uintptr
+	keys  int // # of keys the filter was sized for
+}
+
+func newBloomFilter(keys int) *bloomFilter {
+	if keys < bloomMinKeys {
+		keys = bloomMinKeys
+	}
+	nbits := uint64(64)
+	for nbits < uint64(keys)*bloomBitsPerKey {
+		nbits <<= 1
+	}
+	return &bloomFilter{
+		bits:  make([]uint64, nbits/64),
+		mask:  nbits - 1,
+		seed1: uintptr(runtimer.Fastrand()),
+		seed2: uintptr(runtimer.Fastrand()),
+		keys:  keys,
+	}
+}
+
+func (f *bloomFilter) hashes(t *runtimer.MapType, key string) (uint64, uint64) {
+	p := runtimer.Noescape(unsafe.Pointer(&key))
+	h1 := uint64(t.Key.Alg.Hash(p, f.seed1))
+	h2 := uint64(t.Key.Alg.Hash(p, f.seed2)) | 1
+	return h1, h2
+}
+
+func (f *bloomFilter) add(t *runtimer.MapType, key string) {
+	h1, h2 := f.hashes(t, key)
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) & f.mask
+		f.bits[bit>>6] |= 1 << (bit & 63)
+	}
+}
+
+// mayContain reports false only if key was never added to the filter.
+func (f *bloomFilter) mayContain(t *runtimer.MapType, key string) bool {
+	h1, h2 := f.hashes(t, key)
+	for i := uint64(0); i < bloomHashes; i++ {
+		bit := (h1 + i*h2) & f.mask
+		if f.bits[bit>>6]&(1<<(bit&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
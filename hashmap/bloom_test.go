@@ -0,0 +1,68 @@
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStrMapBloomNoFalseNegatives(t *testing.T) {
+	m := NewStrMap()
+	for i := 0; i < 5000; i++ {
+		m.Put(fmt.Sprint(i), fmt.Sprint(i))
+	}
+	m.WithBloom()
+	// keys added after WithBloom, enough to force the filter to be rebuilt
+	for i := 5000; i < 20000; i++ {
+		m.Put(fmt.Sprint(i), fmt.Sprint(i))
+	}
+	for i := 0; i < 20000; i++ {
+		key := fmt.Sprint(i)
+		vp, ok := m.GetPtrOk(key)
+		if !ok {
+			t.Fatalf("key %q not found with bloom filter enabled", key)
+		}
+		if v := *(*string)(vp); v != key {
+			t.Fatalf("unexpected value for %q: %q", key, v)
+		}
+		if v := *(*string)(m.GetPtr(key)); v != key {
+			t.Fatalf("unexpected value for %q: %q", key, v)
+		}
+	}
+	if _, ok := m.GetPtrOk("missing"); ok {
+		t.Errorf("found a key that was never put")
+	}
+
+	m.Delete("1")
+	if m.bloom != nil {
+		t.Errorf("Delete should drop the bloom filter")
+	}
+	if _, ok := m.GetPtrOk("1"); ok {
+		t.Errorf("deleted key is still found")
+	}
+	if _, ok := m.GetPtrOk("2"); !ok {
+		t.Errorf("key 2 not found after deleting key 1")
+	}
+}
+
+func BenchmarkStrMapMiss_1024(b *testing.B)      { benchmarkStrMapMiss(b, 1024, false) }
+func BenchmarkStrMapMiss_1M(b *testing.B)        { benchmarkStrMapMiss(b, 1<<20, false) }
+func BenchmarkStrMapMissBloom_1024(b *testing.B) { benchmarkStrMapMiss(b, 1024, true) }
+func BenchmarkStrMapMissBloom_1M(b *testing.B)   { benchmarkStrMapMiss(b, 1<<20, true) }
+
+func benchmarkStrMapMiss(b *testing.B, keys int, bloom bool) {
+	m := NewStrMap()
+	for i := 0; i < keys; i++ {
+		m.Put(fmt.Sprint(i), "")
+	}
+	if bloom {
+		m.WithBloom()
+	}
+	misses := make([]string, 1024)
+	for i := range misses {
+		misses[i] = fmt.Sprint("miss", i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = m.GetPtrOk(misses[i&1023])
+	}
+}
@@ -0,0 +1,73 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// bucketAt returns the i-th bucket of the buckets array
+func bucketAt(t *runtimer.MapType, buckets unsafe.Pointer, i uintptr) *bmap {
+	return (*bmap)(runtimer.Add(buckets, i*uintptr(t.Bucketsize)))
+}
+
+// filledCells counts the live cells of b and its overflow chain
+func filledCells(t *runtimer.MapType, b *bmap) int {
+	n := 0
+	for ; b != nil; b = b.overflow(t) {
+		for i := uintptr(0); i < bucketCnt; i++ {
+			if b.tophash[i] >= minTopHash {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// bucketEntries calls fn for each live entry of the given bucket of the
+// current table until fn returns false, and reports whether it got to the end.
+// If the map is growing and the bucket's old bucket hasn't been evacuated yet,
+// the entries are read from the old bucket, like mapiternext does.
+func bucketEntries(t *runtimer.MapType, h *hmap, bucket uintptr, fn func(k, v unsafe.Pointer) bool) bool {
+	b := bucketAt(t, h.buckets, bucket)
+	checkBucket := uintptr(noCheck)
+	if h.growing() {
+		if oldb := bucketAt(t, h.oldbuckets, bucket&h.oldbucketmask()); !evacuated(oldb) {
+			b = oldb
+			if !h.sameSizeGrow() {
+				checkBucket = bucket
+			}
+		}
+	}
+	alg := t.Key.Alg
+	for ; b != nil; b = b.overflow(t) {
+		for i := uintptr(0); i < bucketCnt; i++ {
+			top := b.tophash[i]
+			if top < minTopHash {
+				continue
+			}
+			k := runtimer.Add(unsafe.Pointer(b), dataOffset+i*uintptr(t.Keysize))
+			if t.Indirectkey {
+				k = *((*unsafe.Pointer)(k))
+			}
+			if checkBucket != noCheck {
+				// Skip the keys of the old bucket that go to the other new bucket.
+				if t.Reflexivekey || alg.Equal(k, k) {
+					if alg.Hash(k, uintptr(h.hash0))&(uintptr(1)<<h.B-1) != checkBucket {
+						continue
+					}
+				} else if checkBucket>>(h.B-1) != uintptr(top&1) {
+					continue
+				}
+			}
+			v := runtimer.Add(unsafe.Pointer(b), dataOffset+bucketCnt*uintptr(t.Keysize)+i*uintptr(t.Valuesize))
+			if t.Indirectvalue {
+				v = *((*unsafe.Pointer)(v))
+			}
+			if !fn(k, v) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package hashmap
+
+import "github.com/gramework/runtimer"
+
+// EstimateCardinality estimates the number of entries by counting
+// the filled cells of sampleBuckets consecutive buckets, starting at
+// a random one, and extrapolating to the whole bucket array.
+// It doesn't allocate and is O(sampleBuckets). The estimate gets worse
+// with fewer sampled buckets and with an uneven key distribution.
+// Sampling every bucket gives the exact count.
+func (m *Map) EstimateCardinality(sampleBuckets int) int {
+	h := m.hm
+	if h.len() == 0 || sampleBuckets <= 0 {
+		return 0
+	}
+	t := m.typ
+
+	// While growing, walk the old buckets: each of them is either still
+	// in place or evacuated to one (same size grow) or two new buckets.
+	nbuckets := uintptr(1) << h.B
+	if h.growing() {
+		nbuckets = h.noldbuckets()
+	}
+	sample := uintptr(sampleBuckets)
+	if sample > nbuckets {
+		sample = nbuckets
+	}
+
+	filled := 0
+	start := uintptr(runtimer.Fastrand()) & (nbuckets - 1)
+	for i := uintptr(0); i < sample; i++ {
+		bucket := (start + i) & (nbuckets - 1)
+		if !h.growing() {
+			filled += filledCells(t, bucketAt(t, h.buckets, bucket))
+			continue
+		}
+		if oldb := bucketAt(t, h.oldbuckets, bucket); !evacuated(oldb) {
+			filled += filledCells(t, oldb)
+			continue
+		}
+		filled += filledCells(t, bucketAt(t, h.buckets, bucket))
+		if !h.sameSizeGrow() {
+			filled += filledCells(t, bucketAt(t, h.buckets, bucket+nbuckets))
+		}
+	}
+	return int(uintptr(filled) * nbuckets / sample)
+}
@@ -0,0 +1,45 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Clone returns a copy of the map with its own buckets. The keys and
+// values are copied shallowly, so pointers in them still alias.
+func (m *Map) Clone() *Map {
+	if m.typ == nil {
+		return &Map{}
+	}
+	c := &Map{
+		typ:   m.typ,
+		ikey:  m.ikey,
+		ielem: m.ielem,
+		fn:    m.fn,
+		hm:    makemap(m.typ, int64(m.hm.len()), nil, nil),
+
+		maxChain:   m.maxChain,
+		chainLimit: m.maxChain,
+	}
+	f := c.funcs()
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		runtimer.Typedmemmove(m.typ.Elem, f.assign(c.typ, c.hm, k), v)
+		return true
+	})
+	return c
+}
+
+// Clear deletes all entries, keeping m usable. It replaces the buckets,
+// so a map m was loaded from keeps its entries.
+func (m *Map) Clear() {
+	if m.typ == nil {
+		return
+	}
+	m.hm = makemap(m.typ, 0, nil, nil)
+	m.aliased = false
+	m.chainLimit = m.maxChain
+	if m.pins > 0 {
+		m.hm.flags |= growDisabled
+	}
+}
@@ -0,0 +1,78 @@
+package hashmap
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ConcurrentMap is a Map safe for concurrent use: lookups take a read lock
+// and changes take the write lock, so readers don't block each other.
+type ConcurrentMap struct {
+	lock sync.RWMutex
+	m    *Map
+	elem reflect.Type
+}
+
+// NewConcurrentMap wraps m, which must not be used directly afterwards.
+func NewConcurrentMap(m *Map) *ConcurrentMap {
+	m.funcs() // select them now, so Get doesn't write to m
+	return &ConcurrentMap{
+		m:    m,
+		elem: reflectType(m.typ.Elem),
+	}
+}
+
+// Get returns a copy of the value stored for the key.
+func (c *ConcurrentMap) Get(key interface{}) (interface{}, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	p, ok := c.m.GetPtrOk(key)
+	if !ok {
+		return nil, false
+	}
+	return valueAt(c.elem, p), true
+}
+
+func (c *ConcurrentMap) Put(key, value interface{}) {
+	c.lock.Lock()
+	c.m.Put(key, value)
+	c.lock.Unlock()
+}
+
+// GetOrPut is like Map.GetOrPut, but the lookup and the insert
+// are atomic, so exactly one of concurrent callers stores its value.
+func (c *ConcurrentMap) GetOrPut(key, value interface{}) (actual interface{}, loaded bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.m.GetOrPut(key, value)
+}
+
+func (c *ConcurrentMap) Delete(key interface{}) {
+	c.lock.Lock()
+	c.m.Delete(key)
+	c.lock.Unlock()
+}
+
+func (c *ConcurrentMap) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.m.Len()
+}
+
+// Range calls fn for each entry until fn returns false. It copies the
+// entries under the read lock and calls fn without holding it, so fn
+// may use the map, but it won't see changes made during Range.
+func (c *ConcurrentMap) Range(fn func(key, value interface{}) bool) {
+	c.lock.RLock()
+	entries := make([]interface{}, 0, 2*c.m.Len())
+	c.m.Range(func(k, v interface{}) bool {
+		entries = append(entries, k, v)
+		return true
+	})
+	c.lock.RUnlock()
+	for i := 0; i < len(entries); i += 2 {
+		if !fn(entries[i], entries[i+1]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package hashmap
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	c := NewConcurrentMap(m)
+	const writers, keys = 8, 1000
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				c.Put(w*keys+i, i)
+				if i%2 == 1 {
+					c.Delete(w*keys + i)
+				}
+			}
+		}(w)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				if v, ok := c.Get(w*keys + i); ok && v.(int) != i {
+					t.Errorf("unexpected value for %d: %v", w*keys+i, v)
+				}
+				c.Len()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if n := c.Len(); n != writers*keys/2 {
+		t.Errorf("expected %d entries, got %d", writers*keys/2, n)
+	}
+	visited := 0
+	c.Range(func(key, value interface{}) bool {
+		if key.(int)%2 == 1 {
+			t.Errorf("deleted key %v visited", key)
+		}
+		c.Put(-1, 0) // callbacks can use the map
+		visited++
+		return true
+	})
+	if visited != writers*keys/2 {
+		t.Errorf("Range visited %d entries", visited)
+	}
+	if _, ok := c.Get(-1); !ok {
+		t.Errorf("Put from the Range callback was lost")
+	}
+}
+
+func TestConcurrentMapGetOrPut(t *testing.T) {
+	m, err := LoadMap(map[string]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	c := NewConcurrentMap(m)
+	const callers = 16
+	results := make(chan bool, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			actual, loaded := c.GetOrPut("key", i)
+			if v, _ := c.Get("key"); v != actual {
+				t.Errorf("GetOrPut returned %v, but %v is stored", actual, v)
+			}
+			results <- loaded
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+	stored := 0
+	for loaded := range results {
+		if !loaded {
+			stored++
+		}
+	}
+	if stored != 1 {
+		t.Errorf("%d callers stored their value", stored)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build hashmapdebug
+
+package hashmap
+
+import (
+	"strconv"
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// checkGrow verifies h right after a grow completed: every live key must be
+// in the bucket and have the tophash its hash selects, and the number of
+// live cells must match h.count. A mismatch means evacuate corrupted the map,
+// so it throws. It walks the whole map, so it's only built with the
+// hashmapdebug tag.
+func checkGrow(t *runtimer.MapType, h *hmap) {
+	alg := t.Key.Alg
+	nbuckets := uintptr(1) << h.B
+	n := 0
+	for i := uintptr(0); i < nbuckets; i++ {
+		for b := bucketAt(t, h.buckets, i); b != nil; b = b.overflow(t) {
+			for j := uintptr(0); j < bucketCnt; j++ {
+				if b.tophash[j] < minTopHash {
+					continue
+				}
+				n++
+				k := runtimer.Add(unsafe.Pointer(b), dataOffset+j*uintptr(t.Keysize))
+				if t.Indirectkey {
+					k = *((*unsafe.Pointer)(k))
+				}
+				if !t.Reflexivekey && !alg.Equal(k, k) {
+					continue // NaNs go to random buckets
+				}
+				hash := alg.Hash(k, uintptr(h.hash0))
+				if hash&(nbuckets-1) != i {
+					runtimer.Throw("hashmap: " + t.Key.String() + " key in bucket " +
+						strconv.FormatUint(uint64(i), 10) + " after a grow, its hash selects " +
+						strconv.FormatUint(uint64(hash&(nbuckets-1)), 10))
+				}
+				top := uint8(hash >> (runtimer.PtrSize*8 - 8))
+				if top < minTopHash {
+					top += minTopHash
+				}
+				if top != b.tophash[j] {
+					runtimer.Throw("hashmap: " + t.Key.String() + " key with a wrong tophash after a grow")
+				}
+			}
+		}
+	}
+	if n != h.count {
+		runtimer.Throw("hashmap: " + strconv.Itoa(n) + " live cells after a grow, but the count is " +
+			strconv.Itoa(h.count))
+	}
+}
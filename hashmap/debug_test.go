@@ -0,0 +1,57 @@
+//go:build hashmapdebug
+
+package hashmap
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// The grows below throw if checkGrow finds an inconsistency.
+func TestCheckGrow(t *testing.T) {
+	type point struct{ X, Y int }
+	ints := NewStructMap[int, int]()
+	strs := NewStrMap()
+	points := NewStructMap[point, string]()
+	floats := NewFloat64IMap()
+	ifaces, err := LoadMap(map[interface{}]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 5000; i++ {
+		ints.Put(i, i)
+		strs.Put(fmt.Sprint(i), "")
+		points.Put(point{i, -i}, "")
+		floats.Put(float64(i)/3, nil)
+		if i%10 == 0 {
+			floats.Put(math.NaN(), nil)
+		}
+		switch i % 3 {
+		case 0:
+			ifaces.Put(i, i)
+		case 1:
+			ifaces.Put(fmt.Sprint(i), i)
+		default:
+			ifaces.Put(point{i, i}, i)
+		}
+		if i%7 == 0 {
+			ints.Delete(i / 2)
+		}
+	}
+	// a same size grow
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	m.GrowNow()
+	for m.hm.growing() {
+		m.Put(0, 0)
+	}
+	if floats.Len() != 5500 || ifaces.Len() != 5000 {
+		t.Errorf("unexpected lengths: %d, %d", floats.Len(), ifaces.Len())
+	}
+}
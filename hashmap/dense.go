@@ -0,0 +1,72 @@
+package hashmap
+
+// DenseIntMap is an IntIMap for keys that are mostly in [0, maxKey]:
+// those are stored in a slice indexed by the key, and the other keys
+// fall back to the hashmap.
+type DenseIntMap struct {
+	dense   []interface{}
+	present []bool
+	n       int
+	sparse  *IntIMap
+}
+
+// NewDenseIntMap creates a DenseIntMap with a slice for keys in [0, maxKey].
+func NewDenseIntMap(maxKey int) *DenseIntMap {
+	if maxKey < 0 {
+		maxKey = -1
+	}
+	return &DenseIntMap{
+		dense:   make([]interface{}, maxKey+1),
+		present: make([]bool, maxKey+1),
+		sparse:  NewIntIMap(),
+	}
+}
+
+func (m *DenseIntMap) inRange(key int) bool {
+	return uint(key) < uint(len(m.dense))
+}
+
+// Len returns the number of entries in the map.
+func (m *DenseIntMap) Len() int {
+	return m.n + m.sparse.Len()
+}
+
+// Has reports whether the key is present, even with a nil value
+func (m *DenseIntMap) Has(key int) bool {
+	if m.inRange(key) {
+		return m.present[key]
+	}
+	return m.sparse.Has(key)
+}
+
+// Get returns the value stored for the key, or nil, false
+func (m *DenseIntMap) Get(key int) (interface{}, bool) {
+	if m.inRange(key) {
+		return m.dense[key], m.present[key]
+	}
+	return m.sparse.Get(key)
+}
+
+func (m *DenseIntMap) Put(key int, value interface{}) {
+	if m.inRange(key) {
+		if !m.present[key] {
+			m.present[key] = true
+			m.n++
+		}
+		m.dense[key] = value
+		return
+	}
+	m.sparse.Put(key, value)
+}
+
+func (m *DenseIntMap) Delete(key int) {
+	if m.inRange(key) {
+		if m.present[key] {
+			m.present[key] = false
+			m.dense[key] = nil
+			m.n--
+		}
+		return
+	}
+	m.sparse.Delete(key)
+}
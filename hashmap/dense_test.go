@@ -0,0 +1,57 @@
+package hashmap
+
+import "testing"
+
+func TestDenseIntMap(t *testing.T) {
+	m := NewDenseIntMap(9)
+	keys := []int{-1, 0, 1, 9, 10, 1 << 40}
+	for _, k := range keys {
+		m.Put(k, k)
+	}
+	m.Put(5, nil)
+	if n := m.Len(); n != len(keys)+1 {
+		t.Fatalf("expected %d entries, got %d", len(keys)+1, n)
+	}
+	for _, k := range keys {
+		if v, ok := m.Get(k); !ok || v != k {
+			t.Errorf("key %d: got %v, %v", k, v, ok)
+		}
+	}
+	if v, ok := m.Get(5); !ok || v != nil {
+		t.Errorf("key 5: got %v, %v", v, ok)
+	}
+	if m.Has(2) || m.Has(11) {
+		t.Errorf("missing keys were found")
+	}
+	for _, k := range []int{9, 10, 9, 10} {
+		m.Delete(k)
+		if m.Has(k) {
+			t.Errorf("key %d was not deleted", k)
+		}
+	}
+	if n := m.Len(); n != len(keys)-1 {
+		t.Errorf("expected %d entries after deletes, got %d", len(keys)-1, n)
+	}
+}
+
+func BenchmarkDenseIntMapGet_1024(b *testing.B) {
+	m := NewDenseIntMap(1023)
+	for i := 0; i < 1024; i++ {
+		m.Put(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get(i & 1023)
+	}
+}
+
+func BenchmarkIntIMapGet_1024(b *testing.B) {
+	m := NewIntIMap()
+	for i := 0; i < 1024; i++ {
+		m.Put(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Get(i & 1023)
+	}
+}
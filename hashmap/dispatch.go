@@ -0,0 +1,103 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// mapFuncs is a set of map access functions
+// taking a pointer to the key, like the generic ones do.
+type mapFuncs struct {
+	access1 func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer
+	access2 func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) (unsafe.Pointer, bool)
+	assign  func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer
+	delete  func(t *runtimer.MapType, h *hmap, key unsafe.Pointer)
+}
+
+var genericFuncs = mapFuncs{
+	access1: mapaccess1,
+	access2: mapaccess2,
+	assign:  mapassign,
+	delete:  mapdelete,
+}
+
+var fast32Funcs = mapFuncs{
+	access1: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapaccess1_fast32(t, h, *(*uint32)(key))
+	},
+	access2: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) (unsafe.Pointer, bool) {
+		return mapaccess2_fast32(t, h, *(*uint32)(key))
+	},
+	assign: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapassign_fast32(t, h, *(*uint32)(key))
+	},
+	delete: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) {
+		mapdelete_fast32(t, h, *(*uint32)(key))
+	},
+}
+
+var fast64Funcs = mapFuncs{
+	access1: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapaccess1_fast64(t, h, *(*uint64)(key))
+	},
+	access2: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) (unsafe.Pointer, bool) {
+		return mapaccess2_fast64(t, h, *(*uint64)(key))
+	},
+	assign: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapassign_fast64(t, h, *(*uint64)(key))
+	},
+	delete: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) {
+		mapdelete_fast64(t, h, *(*uint64)(key))
+	},
+}
+
+var faststrFuncs = mapFuncs{
+	access1: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapaccess1_faststr(t, h, *(*string)(key))
+	},
+	access2: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) (unsafe.Pointer, bool) {
+		return mapaccess2_faststr(t, h, *(*string)(key))
+	},
+	assign: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer {
+		return mapassign_faststr(t, h, *(*string)(key))
+	},
+	delete: func(t *runtimer.MapType, h *hmap, key unsafe.Pointer) {
+		mapdelete_faststr(t, h, *(*string)(key))
+	},
+}
+
+// selectFuncs picks the fastest access functions for the map type, the same
+// way the compiler does for built-in maps. The fast versions can't handle
+// values stored indirectly.
+func selectFuncs(t *runtimer.MapType) *mapFuncs {
+	if t.Indirectvalue {
+		return &genericFuncs
+	}
+	switch t.Key.Kind & kindMask {
+	case kindInt32, kindUint32:
+		return &fast32Funcs
+	case kindInt64, kindUint64:
+		return &fast64Funcs
+	case kindInt, kindUint, kindUintptr:
+		if t.Key.Size == 4 {
+			return &fast32Funcs
+		}
+		return &fast64Funcs
+	case kindString:
+		return &faststrFuncs
+	}
+	return &genericFuncs
+}
+
+// funcs returns the access functions of the map,
+// selecting them on first use.
+func (m *Map) funcs() *mapFuncs {
+	if m.fn == nil {
+		if m.typ == nil {
+			return &genericFuncs
+		}
+		m.fn = selectFuncs(m.typ)
+	}
+	return m.fn
+}
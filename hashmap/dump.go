@@ -0,0 +1,54 @@
+package hashmap
+
+import (
+	"fmt"
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// BucketCell describes a cell of a bucket, see DumpBucket.
+type BucketCell struct {
+	Overflow int // # of the bucket in the chain, 0 for the top-level one
+	Cell     int
+	Tophash  uint8
+	Occupied bool
+	Key      string // set for occupied cells of string and integer keys
+}
+
+// DumpBucket describes every cell of the top-level bucket with the given
+// index and of its overflow chain, for debugging collisions. While the map
+// is growing, the entries of unevacuated old buckets aren't in the dump.
+// It returns nil if there's no such bucket.
+func (m *Map) DumpBucket(index int) []BucketCell {
+	h := m.hm
+	if h == nil || h.buckets == nil || index < 0 || uintptr(index) >= uintptr(1)<<h.B {
+		return nil
+	}
+	kind := m.typ.Key.Kind & kindMask
+	readable := kind == kindString || kind >= kindInt && kind <= kindUintptr
+	kt := reflectType(m.typ.Key)
+
+	var cells []BucketCell
+	ovf := 0
+	for b := bucketAt(m.typ, h.buckets, uintptr(index)); b != nil; b = b.overflow(m.typ) {
+		for i := uintptr(0); i < bucketCnt; i++ {
+			c := BucketCell{
+				Overflow: ovf,
+				Cell:     int(i),
+				Tophash:  b.tophash[i],
+				Occupied: b.tophash[i] >= minTopHash,
+			}
+			if c.Occupied && readable {
+				k := runtimer.Add(unsafe.Pointer(b), dataOffset+i*uintptr(m.typ.Keysize))
+				if m.typ.Indirectkey {
+					k = *((*unsafe.Pointer)(k))
+				}
+				c.Key = fmt.Sprint(valueAt(kt, k))
+			}
+			cells = append(cells, c)
+		}
+		ovf++
+	}
+	return cells
+}
@@ -1,6 +1,7 @@
 package hashmap
 
 import (
+	"reflect"
 	"unsafe" // #nosec
 
 	"github.com/gramework/runtimer"
@@ -11,6 +12,35 @@ type emptyInterface struct {
 	word unsafe.Pointer
 }
 
+// reflectType returns the reflect.Type for the runtime type t.
+func reflectType(t *runtimer.Type) reflect.Type {
+	var i interface{}
+	(*emptyInterface)(unsafe.Pointer(&i)).typ = t
+	return reflect.TypeOf(i)
+}
+
+// valueAt returns a copy of the value of type t stored at p.
+func valueAt(t reflect.Type, p unsafe.Pointer) interface{} {
+	return reflect.NewAt(t, p).Elem().Interface()
+}
+
 type flag uintptr
 
 const flagIndir = 1 << 7
+
+// Kinds of runtime types, see runtime/typekind.go
+const (
+	kindInt           = 2
+	kindInt32         = 5
+	kindInt64         = 6
+	kindUint          = 7
+	kindUint32        = 10
+	kindUint64        = 11
+	kindUintptr       = 12
+	kindPtr           = 22
+	kindString        = 24
+	kindUnsafePointer = 26
+
+	kindDirectIface = 1 << 5
+	kindMask        = (1 << 5) - 1
+)
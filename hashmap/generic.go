@@ -0,0 +1,18 @@
+package hashmap
+
+import "unsafe" // #nosec
+
+// Get returns a typed pointer to the value stored by key.
+// It returns (nil, false) if the key is missing or if the size of V
+// doesn't match the size of the map's value type.
+func Get[V any](m *Map, key interface{}) (*V, bool) {
+	var zero V
+	if uintptr(m.typ.Elem.Size) != unsafe.Sizeof(zero) {
+		return nil, false
+	}
+	p, ok := m.GetPtrOk(key)
+	if !ok {
+		return nil, false
+	}
+	return (*V)(p), true
+}
@@ -0,0 +1,22 @@
+package hashmap
+
+import "testing"
+
+func TestGenericGet(t *testing.T) {
+	m, err := LoadMap(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if v, ok := Get[int](m, "a"); !ok || *v != 1 {
+		t.Errorf("expected to get 1 by key a")
+	}
+	if v, ok := Get[int](m, "b"); ok || v != nil {
+		t.Errorf("missing key returned a value")
+	}
+	if v, ok := Get[int8](m, "a"); ok || v != nil {
+		t.Errorf("expected a value of a different size to be rejected")
+	}
+	if v, ok := Get[[4]int](m, "a"); ok || v != nil {
+		t.Errorf("expected a value of a different size to be rejected")
+	}
+}
@@ -0,0 +1,159 @@
+package hashmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// gobMap is the gob encoding of a Map. The key and value types are
+// recovered on decoding from zero values sent as interfaces, so any
+// types other than gob's basic ones must be registered with gob.Register.
+// gob sends the value a pointer points to, and no nil pointers, so pointer
+// types are sent as the type they point to and the number of indirections;
+// a nil at any of them is sent as a nil interface.
+type gobMap struct {
+	Key, Elem           interface{} // nil for interface{} types
+	KeyIface, ElemIface bool
+	KeyPtrs, ElemPtrs   int
+	Keys, Values        []interface{}
+}
+
+var errGobIface = errors.New("only the interface{} type is supported for interface keys and values")
+
+// GobEncode implements gob.GobEncoder
+func (m *Map) GobEncode() ([]byte, error) {
+	if m.typ == nil {
+		return nil, ErrNoType
+	}
+	kt, vt := reflectType(m.typ.Key), reflectType(m.typ.Elem)
+	g := gobMap{
+		Keys:   make([]interface{}, 0, m.Len()),
+		Values: make([]interface{}, 0, m.Len()),
+	}
+	var err error
+	if g.Key, g.KeyIface, g.KeyPtrs, err = gobZero(kt); err != nil {
+		return nil, err
+	}
+	if g.Elem, g.ElemIface, g.ElemPtrs, err = gobZero(vt); err != nil {
+		return nil, err
+	}
+	m.Range(func(k, v interface{}) bool {
+		g.Keys = append(g.Keys, gobDeref(k, g.KeyPtrs))
+		g.Values = append(g.Values, gobDeref(v, g.ElemPtrs))
+		return true
+	})
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&g); err != nil {
+		return nil, fmt.Errorf("hashmap: can't gob encode %s: %w", reflect.MapOf(kt, vt), err)
+	}
+	return buf.Bytes(), nil
+}
+
+func gobZero(t reflect.Type) (zero interface{}, iface bool, ptrs int, err error) {
+	base := t
+	for ; base.Kind() == reflect.Ptr; ptrs++ {
+		base = base.Elem()
+	}
+	if base.Kind() != reflect.Interface {
+		return reflect.Zero(base).Interface(), false, ptrs, nil
+	}
+	if ptrs != 0 || t.NumMethod() != 0 {
+		return nil, false, 0, fmt.Errorf("hashmap: can't gob encode %s: %w", t, errGobIface)
+	}
+	return nil, true, 0, nil
+}
+
+// gobDeref returns the value v points to through ptrs pointers,
+// or nil if one of them is nil
+func gobDeref(v interface{}, ptrs int) interface{} {
+	if ptrs == 0 {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	for i := 0; i < ptrs; i++ {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	return rv.Interface()
+}
+
+// GobDecode implements gob.GobDecoder. It replaces m with a new map
+// of the encoded type, which doesn't alias any built-in map.
+func (m *Map) GobDecode(data []byte) error {
+	var g gobMap
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return fmt.Errorf("hashmap: can't gob decode a map: %w", err)
+	}
+	kt, err := gobType(g.Key, g.KeyIface, g.KeyPtrs)
+	if err != nil {
+		return err
+	}
+	vt, err := gobType(g.Elem, g.ElemIface, g.ElemPtrs)
+	if err != nil {
+		return err
+	}
+	if len(g.Keys) != len(g.Values) {
+		return fmt.Errorf("hashmap: can't gob decode a map: %d keys, but %d values", len(g.Keys), len(g.Values))
+	}
+	mt := reflect.MapOf(kt, vt)
+	mv := reflect.MakeMapWithSize(mt, len(g.Keys))
+	for i, k := range g.Keys {
+		kv, vv := gobValue(k, kt), gobValue(g.Values[i], vt)
+		if !kv.IsValid() || !vv.IsValid() {
+			return fmt.Errorf("hashmap: can't gob decode %s: entry %d is of type %T: %T", mt, i, k, g.Values[i])
+		}
+		mv.SetMapIndex(kv, vv)
+	}
+	loaded, err := LoadMap(mv.Interface())
+	if err != nil {
+		return err
+	}
+	loaded.aliased = false
+	*m = *loaded
+	return nil
+}
+
+func gobType(zero interface{}, iface bool, ptrs int) (reflect.Type, error) {
+	if iface {
+		return reflect.TypeFor[interface{}](), nil
+	}
+	if zero == nil || ptrs < 0 {
+		return nil, errors.New("hashmap: can't gob decode a map: missing key or value type")
+	}
+	t := reflect.TypeOf(zero)
+	for i := 0; i < ptrs; i++ {
+		t = reflect.PointerTo(t)
+	}
+	return t, nil
+}
+
+// gobValue returns v as a value of type t, or an invalid Value
+// if v isn't assignable to t. For a pointer type, v is the value
+// it points to, see gobMap.
+func gobValue(v interface{}, t reflect.Type) reflect.Value {
+	if v == nil {
+		if t.Kind() == reflect.Interface || t.Kind() == reflect.Ptr {
+			return reflect.Zero(t)
+		}
+		return reflect.Value{}
+	}
+	if t.Kind() == reflect.Ptr {
+		ev := gobValue(v, t.Elem())
+		if !ev.IsValid() {
+			return ev
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(ev)
+		return p
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.Type().AssignableTo(t) {
+		return reflect.Value{}
+	}
+	return rv
+}
@@ -0,0 +1,79 @@
+package hashmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"testing"
+)
+
+func gobRoundTrip(t *testing.T, src interface{}) (*Map, error) {
+	m, err := LoadMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		return nil, err
+	}
+	var res Map
+	if err := gob.NewDecoder(&buf).Decode(&res); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	return &res, nil
+}
+
+func TestMapGobStrInt(t *testing.T) {
+	src := map[string]int{}
+	for i := 0; i < 100; i++ {
+		src[fmt.Sprint(i)] = i
+	}
+	res, err := gobRoundTrip(t, src)
+	if err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+	if res.KeyType() != "string" || res.Len() != len(src) || res.IsAliased() {
+		t.Fatalf("unexpected decoded map: %s keys, %d entries", res.KeyType(), res.Len())
+	}
+	for k, v := range src {
+		if p, ok := res.GetPtrOk(k); !ok || *(*int)(p) != v {
+			t.Errorf("entry %s=%d didn't round-trip", k, v)
+		}
+	}
+}
+
+func TestMapGobInterfaceValues(t *testing.T) {
+	res, err := gobRoundTrip(t, map[int]interface{}{1: "one", 2: 2.5, 3: nil})
+	if err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+	for k, v := range map[int]interface{}{1: "one", 2: 2.5, 3: nil} {
+		if p, ok := res.GetPtrOk(k); !ok || *(*interface{})(p) != v {
+			t.Errorf("entry %d=%v didn't round-trip", k, v)
+		}
+	}
+}
+
+func TestMapGobPointerValues(t *testing.T) {
+	one := 1
+	res, err := gobRoundTrip(t, map[string]*int{"one": &one, "nil": nil})
+	if err != nil {
+		t.Fatalf("Encode failed: %s", err)
+	}
+	if p, ok := res.GetPtrOk("one"); !ok || *(**int)(p) == nil || **(**int)(p) != 1 {
+		t.Errorf("entry one=&1 didn't round-trip")
+	}
+	if p, ok := res.GetPtrOk("nil"); !ok || *(**int)(p) != nil {
+		t.Errorf("entry nil=nil didn't round-trip")
+	}
+}
+
+func TestMapGobUnregistered(t *testing.T) {
+	type unregistered struct{ X int }
+	if _, err := gobRoundTrip(t, map[string]unregistered{"a": {1}}); err == nil {
+		t.Errorf("expected an error for an unregistered value type")
+	}
+	if _, err := (&Map{}).GobEncode(); err != ErrNoType {
+		t.Errorf("expected ErrNoType for a zero Map, got %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// SetMaxOverflowChain bounds the probe length of the inserts through m:
+// when a Put leaves a chain of more than n overflow buckets, the map is
+// rehashed with a new seed, which splits the keys that only collided under
+// the old one. Keys whose hashes collide under any seed stay chained, so
+// the next rehash waits until the chain grows by another n buckets.
+// A rehash isn't incremental, it moves every entry at once.
+//
+// The check hashes the key again and walks its chain, on each insert of a
+// new key that adds an overflow bucket, and on every insert of a new key
+// once the map has 1<<16 buckets or more. Keys not equal to themselves,
+// like NaNs, are never checked. Only the writes through m are bounded:
+// StrMap, the I-maps, TypedMap.Put and the shards of a ShardedMap don't
+// call it, and neither does the built-in map m was loaded from.
+//
+// A non-positive n turns the bound off, which is the default. Like the
+// other writes, it must not race with the use of m.
+func (m *Map) SetMaxOverflowChain(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.maxChain = n
+	m.chainLimit = n
+}
+
+// WouldGrow reports whether inserting one more key would start growing
+// the map, so callers can grow it ahead of time off the hot path.
+func (m *Map) WouldGrow() bool {
+	h := m.hm
+	if h == nil || !h.canGrow() {
+		return false
+	}
+	return overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)
+}
+
+// GrowNow starts growing the map right away, so the new bucket array is
+// allocated when the caller chooses rather than by an insert on the hot path.
+// Like a grow started by an insert, it doubles the buckets if the map is over
+// its load factor and otherwise repacks them into a same size array.
+// The entries are then evacuated incrementally by subsequent writes.
+// It does nothing if the map is already growing, is pinned
+// or has no buckets yet.
+func (m *Map) GrowNow() {
+	h := m.hm
+	if h == nil || h.buckets == nil || !h.canGrow() {
+		return
+	}
+	if h.flags&hashWriting != 0 {
+		runtimer.Throw("concurrent map writes")
+	}
+	h.flags |= hashWriting
+	hashGrow(m.typ, h)
+	m.grows++
+	h.flags &^= hashWriting
+}
+
+// GrowCount returns the number of grows and rehashes the map started since
+// it was created or since ResetGrowCount, so load tests can check that
+// a presized map never grows. Only grows started through the Map count: not those
+// of the built-in map it was loaded from, or of other wrappers of it.
+func (m *Map) GrowCount() uint64 {
+	return m.grows
+}
+
+// ResetGrowCount sets GrowCount back to zero.
+func (m *Map) ResetGrowCount() {
+	m.grows = 0
+}
+
+// assign is funcs().assign counting grows and bounding the chains for
+// SetMaxOverflowChain. The hmap can't hold that state, its layout is the
+// runtime's, but mapassign starts at most one grow, which always replaces
+// the bucket array, and counts the overflow buckets it adds in noverflow.
+func (m *Map) assign(key unsafe.Pointer) unsafe.Pointer {
+	h := m.hm
+	buckets, count, noverflow := h.buckets, h.count, h.noverflow
+	p := m.funcs().assign(m.typ, h, key)
+	if buckets != nil && h.buckets != buckets {
+		m.grows++
+	}
+	// noverflow is only incremented for some overflow buckets on big maps
+	if m.maxChain > 0 && h.count != count && (h.noverflow != noverflow || h.B >= 16) {
+		// a NaN can't be found again, and hashes to a random bucket
+		reflexive := m.typ.Reflexivekey || m.typ.Key.Alg.Equal(key, key)
+		if reflexive && h.flags&growDisabled == 0 && m.chainLen(key) > m.chainLimit {
+			p = m.rehash(key)
+			m.chainLimit = m.maxChain
+			if n := m.chainLen(key); n > m.chainLimit {
+				m.chainLimit = n + m.maxChain
+			}
+		}
+	}
+	return p
+}
+
+// chainLen returns the number of overflow buckets chained to the bucket of
+// the key. The key must be in the map, so its bucket isn't being evacuated.
+func (m *Map) chainLen(key unsafe.Pointer) int {
+	t, h := m.typ, m.hm
+	hash := t.Key.Alg.Hash(key, uintptr(h.hash0))
+	n := 0
+	for b := bucketAt(t, h.buckets, hash&(uintptr(1)<<h.B-1)).overflow(t); b != nil; b = b.overflow(t) {
+		n++
+	}
+	return n
+}
+
+// rehash moves all entries to new buckets, sized for them, under a new hash
+// seed, and returns the new slot of the value of key, which must be in the
+// map and equal to itself. The old buckets are marked evacuated, so iterators started before
+// look the entries up in the new buckets, like after a grow.
+func (m *Map) rehash(key unsafe.Pointer) (p unsafe.Pointer) {
+	t, h := m.typ, m.hm
+	finishGrow(t, h)
+	nh := makemap(t, int64(h.count), nil, nil)
+	f := m.funcs()
+	mapiterate(t, h, func(k, v unsafe.Pointer) bool {
+		runtimer.Typedmemmove(t.Elem, f.assign(t, nh, k), v)
+		return true
+	})
+	finishGrow(t, nh)
+	// the key is in nh, so assign finds it and doesn't grow nh
+	p = f.assign(t, nh, key)
+
+	for i := uintptr(0); i < uintptr(1)<<h.B; i++ {
+		for b := bucketAt(t, h.buckets, i); b != nil; b = b.overflow(t) {
+			for j := range b.tophash {
+				if b.tophash[j] == empty {
+					b.tophash[j] = evacuatedEmpty
+				} else {
+					b.tophash[j] = evacuatedX
+				}
+			}
+		}
+	}
+	*h = *nh
+	m.grows++
+	return p
+}
+
+// Pin keeps the entries of the map in place until unpin is called, so value
+// pointers obtained in the meantime stay valid. It completes a grow in progress
+// and then disables growing: Puts made while the map is pinned don't wait,
+// they chain overflow buckets instead, which makes lookups slower if many
+// keys are added. Pins may be nested.
+//
+// Pin only holds for writes through m. Writes to the built-in map m was
+// loaded from ignore it, and so does every other Map loaded from that map:
+// they share the flag disabling growth, so pinning and unpinning any of them
+// lets m grow while it's still pinned. Pin one Map per built-in map at most.
+func (m *Map) Pin() (unpin func()) {
+	h := m.hm
+	if h == nil {
+		return func() {}
+	}
+	if m.pins == 0 {
+		finishGrow(m.typ, h)
+		h.flags |= growDisabled
+	}
+	m.pins++
+	unpinned := false
+	return func() {
+		if unpinned {
+			return
+		}
+		unpinned = true
+		m.pins--
+		if m.pins == 0 {
+			m.hm.flags &^= growDisabled // not h, Clear may have replaced it
+		}
+	}
+}
+
+// finishGrow evacuates all buckets of a grow in progress.
+func finishGrow(t *runtimer.MapType, h *hmap) {
+	if !h.growing() {
+		return
+	}
+	if h.flags&hashWriting != 0 {
+		runtimer.Throw("concurrent map writes")
+	}
+	h.flags |= hashWriting
+	for h.growing() {
+		evacuate(t, h, h.nevacuate)
+	}
+	h.flags &^= hashWriting
+}
@@ -93,10 +93,11 @@ const (
 	minTopHash     = 4 // minimum tophash for a normal filled cell.
 
 	// flags
-	iterator     = 1 // there may be an iterator using buckets
-	oldIterator  = 2 // there may be an iterator using oldbuckets
-	hashWriting  = 4 // a goroutine is writing to the map
-	sameSizeGrow = 8 // the current map growth is to a new map of the same size
+	iterator     = 1  // there may be an iterator using buckets
+	oldIterator  = 2  // there may be an iterator using oldbuckets
+	hashWriting  = 4  // a goroutine is writing to the map
+	sameSizeGrow = 8  // the current map growth is to a new map of the same size
+	growDisabled = 16 // inserts must not start growing the map, see Map.Pin
 
 	// sentinel bucket ID for iterator checks
 	noCheck = 1<<(8*runtimer.PtrSize) - 1
@@ -278,7 +279,7 @@ func makemap(t *runtimer.MapType, hint int64, h *hmap, bucket unsafe.Pointer) *h
 	// If hint is large zeroing this memory could take a while.
 	buckets := bucket
 	if B != 0 {
-		buckets = runtimer.Newarray((*runtimer.Type)((unsafe.Pointer)(t.Bucket)), 1<<B)
+		buckets = newarray((*runtimer.Type)((unsafe.Pointer)(t.Bucket)), 1<<B)
 	}
 
 	// initialize Hmap
@@ -492,7 +493,7 @@ func mapassign(t *runtimer.MapType, h *hmap, key unsafe.Pointer) unsafe.Pointer
 	h.flags |= hashWriting
 
 	if h.buckets == nil {
-		h.buckets = runtimer.Newarray((*runtimer.Type)((unsafe.Pointer)(t.Bucket)), 1)
+		h.buckets = newarray((*runtimer.Type)((unsafe.Pointer)(t.Bucket)), 1)
 	}
 
 again:
@@ -544,7 +545,7 @@ again:
 
 	// If we hit the max load factor or we have too many overflow buckets,
 	// and we're not already in the middle of growing, start growing.
-	if !h.growing() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
+	if h.canGrow() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
 		hashGrow(t, h)
 		goto again // Growing the table invalidates everything, so try again
 	}
@@ -854,7 +855,7 @@ func hashGrow(t *runtimer.MapType, h *hmap) {
 		h.flags |= sameSizeGrow
 	}
 	oldbuckets := h.buckets
-	newbuckets := runtimer.Newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1<<(h.B+bigger))
+	newbuckets := newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1<<(h.B+bigger))
 	flags := h.flags &^ (iterator | oldIterator)
 	if h.flags&iterator != 0 {
 		flags |= oldIterator
@@ -905,6 +906,11 @@ func (h *hmap) growing() bool {
 	return h.oldbuckets != nil
 }
 
+// canGrow reports whether an insert may start growing h.
+func (h *hmap) canGrow() bool {
+	return !h.growing() && h.flags&growDisabled == 0
+}
+
 // sameSizeGrow reports whether the current growth is to a map of the same size.
 func (h *hmap) sameSizeGrow() bool {
 	return h.flags&sameSizeGrow != 0
@@ -1100,6 +1106,7 @@ func evacuate(t *runtimer.MapType, h *hmap, oldbucket uintptr) {
 				h.overflow[1] = nil
 			}
 			h.flags &^= sameSizeGrow
+			checkGrow(t, h)
 		}
 	}
 }
@@ -412,7 +412,7 @@ func mapassign_fast32(t *runtimer.MapType, h *hmap, key uint32) unsafe.Pointer {
 	h.flags |= hashWriting
 
 	if h.buckets == nil {
-		h.buckets = runtimer.Newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
+		h.buckets = newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
 	}
 
 again:
@@ -457,7 +457,7 @@ again:
 
 	// If we hit the max load factor or we have too many overflow buckets,
 	// and we're not already in the middle of growing, start growing.
-	if !h.growing() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
+	if h.canGrow() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
 		hashGrow(t, h)
 		goto again // Growing the table invalidates everything, so try again
 	}
@@ -497,7 +497,7 @@ func mapassign_fast64(t *runtimer.MapType, h *hmap, key uint64) unsafe.Pointer {
 	h.flags |= hashWriting
 
 	if h.buckets == nil {
-		h.buckets = runtimer.Newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
+		h.buckets = newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
 	}
 
 again:
@@ -542,7 +542,7 @@ again:
 
 	// If we hit the max load factor or we have too many overflow buckets,
 	// and we're not already in the middle of growing, start growing.
-	if !h.growing() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
+	if h.canGrow() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
 		hashGrow(t, h)
 		goto again // Growing the table invalidates everything, so try again
 	}
@@ -583,7 +583,7 @@ func mapassign_faststr(t *runtimer.MapType, h *hmap, ky string) unsafe.Pointer {
 	h.flags |= hashWriting
 
 	if h.buckets == nil {
-		h.buckets = runtimer.Newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
+		h.buckets = newarray(runtimer.PtrToType(unsafe.Pointer(t.Bucket)), 1)
 	}
 
 again:
@@ -632,7 +632,7 @@ again:
 
 	// If we hit the max load factor or we have too many overflow buckets,
 	// and we're not already in the middle of growing, start growing.
-	if !h.growing() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
+	if h.canGrow() && (overLoadFactor(int64(h.count), h.B) || tooManyOverflowBuckets(h.noverflow, h.B)) {
 		hashGrow(t, h)
 		goto again // Growing the table invalidates everything, so try again
 	}
@@ -136,3 +136,109 @@ func benchmarkHashMapPtr(b *testing.B, keys int, two bool) {
 		}
 	}
 }
+
+func BenchmarkHashMapGenericInt_1024(b *testing.B)  { benchmarkHashMapGenericInt(b, 1024, false) }
+func BenchmarkHashMapGenericInt_1M(b *testing.B)    { benchmarkHashMapGenericInt(b, 1<<20, false) }
+func BenchmarkHashMapGenericInt2_1024(b *testing.B) { benchmarkHashMapGenericInt(b, 1024, true) }
+func BenchmarkHashMapGenericInt2_1M(b *testing.B)   { benchmarkHashMapGenericInt(b, 1<<20, true) }
+func BenchmarkHashMapGenericSmallStr_1024(b *testing.B) {
+	benchmarkHashMapGenericSmallStr(b, 1024, false)
+}
+func BenchmarkHashMapGenericSmallStr_1M(b *testing.B) {
+	benchmarkHashMapGenericSmallStr(b, 1<<20, false)
+}
+func BenchmarkHashMapGenericSmallStr2_1024(b *testing.B) {
+	benchmarkHashMapGenericSmallStr(b, 1024, true)
+}
+func BenchmarkHashMapGenericSmallStr2_1M(b *testing.B) {
+	benchmarkHashMapGenericSmallStr(b, 1<<20, true)
+}
+
+func benchmarkHashMapGenericInt(b *testing.B, keys int, two bool) {
+	m, err := LoadMap(map[int]bool{})
+	if err != nil {
+		b.Errorf("Can't load map: %s", err)
+		b.FailNow()
+	}
+	for i := 0; i < keys; i++ {
+		m.Put(i, true)
+	}
+	b.ResetTimer()
+	var key interface{} = keys + 1
+	for i := 0; i < b.N; i++ {
+		if two {
+			_, _ = m.GetPtrOk(key)
+		} else {
+			_ = m.GetPtr(key)
+		}
+	}
+}
+
+func benchmarkHashMapGenericSmallStr(b *testing.B, keys int, two bool) {
+	m, err := LoadMap(map[string]bool{})
+	if err != nil {
+		b.Errorf("Can't load map: %s", err)
+		b.FailNow()
+	}
+	for i := 0; i < keys; i++ {
+		m.Put(fmt.Sprint(i), true)
+	}
+	var key interface{} = fmt.Sprint(keys + 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if two {
+			_, _ = m.GetPtrOk(key)
+		} else {
+			_ = m.GetPtr(key)
+		}
+	}
+}
+
+func BenchmarkHashMapGenericBigStr_8(b *testing.B)    { benchmarkHashMapGenericBigStr(b, 8, false) }
+func BenchmarkHashMapGenericBigStr_512(b *testing.B)  { benchmarkHashMapGenericBigStr(b, 512, false) }
+func BenchmarkHashMapGenericBigStr2_8(b *testing.B)   { benchmarkHashMapGenericBigStr(b, 8, true) }
+func BenchmarkHashMapGenericBigStr2_512(b *testing.B) { benchmarkHashMapGenericBigStr(b, 512, true) }
+
+func benchmarkHashMapGenericBigStr(b *testing.B, keys int, two bool) {
+	m, err := LoadMap(map[string]bool{})
+	if err != nil {
+		b.Errorf("Can't load map: %s", err)
+		b.FailNow()
+	}
+	for i := 0; i < keys; i++ {
+		suffix := fmt.Sprint(i)
+		key := strings.Repeat("X", 1<<20-len(suffix)) + suffix
+		m.Put(key, true)
+	}
+	var key interface{} = strings.Repeat("X", 1<<20-1) + "k"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if two {
+			_, _ = m.GetPtrOk(key)
+		} else {
+			_ = m.GetPtr(key)
+		}
+	}
+}
+
+func BenchmarkStrMapBulkPut_1M(b *testing.B)     { benchmarkStrMapBulkPut(b, 1<<20, false) }
+func BenchmarkStrMapBulkPutHint_1M(b *testing.B) { benchmarkStrMapBulkPut(b, 1<<20, true) }
+
+func benchmarkStrMapBulkPut(b *testing.B, keys int, hint bool) {
+	k := make([]string, keys)
+	for i := range k {
+		k[i] = fmt.Sprint(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var m *StrMap
+		if hint {
+			m = NewStrMap(int32(keys))
+		} else {
+			m = NewStrMap()
+		}
+		for _, key := range k {
+			m.Put(key, key)
+		}
+	}
+}
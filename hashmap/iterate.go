@@ -0,0 +1,29 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// len is like the len() builtin: it is safe to call on a nil map.
+func (h *hmap) len() int {
+	if h == nil {
+		return 0
+	}
+	return h.count
+}
+
+// mapiterate walks every live entry of h in iteration order and calls fn
+// with pointers to the key and the value until fn returns false.
+func mapiterate(t *runtimer.MapType, h *hmap, fn func(k, v unsafe.Pointer) bool) {
+	if h.len() == 0 {
+		return
+	}
+	var it hiter
+	for mapiterinit(t, h, &it); it.key != nil; mapiternext(&it) {
+		if !fn(it.key, it.value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package hashmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"unsafe" // #nosec
+)
+
+// MarshalJSON encodes the map as a JSON object with the entries
+// in iteration order
+func (m *StrMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	var err error
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		if err = writeJSONString(&buf, *(*string)(k)); err != nil {
+			return false
+		}
+		buf.WriteByte(':')
+		err = writeJSONString(&buf, *(*string)(v))
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) error {
+	b, err := json.Marshal(s)
+	buf.Write(b)
+	return err
+}
+
+// UnmarshalJSON puts the entries of a JSON object of strings into the map,
+// keeping the entries it already has. It initializes a zero StrMap.
+func (m *StrMap) UnmarshalJSON(data []byte) error {
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	if m.typ == nil {
+		*m = *NewStrMap()
+	}
+	for k, v := range entries {
+		m.Put(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package hashmap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStrMapJSON(t *testing.T) {
+	for _, m := range []*StrMap{{}, NewStrMap()} {
+		if b, err := json.Marshal(m); err != nil || string(b) != "{}" {
+			t.Errorf("empty map marshaled to %s, %v", b, err)
+		}
+	}
+
+	src := map[string]string{"a": "1", "quote\"": "line\nbreak", "": "<html>", "unicode": "ключ"}
+	m, err := LoadStrMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	var plain map[string]string
+	if err := json.Unmarshal(b, &plain); err != nil {
+		t.Fatalf("invalid JSON %s: %s", b, err)
+	}
+	if len(plain) != len(src) {
+		t.Errorf("expected %d entries, got %s", len(src), b)
+	}
+
+	var res StrMap
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+	for k, v := range src {
+		if got, ok := res.Get(k); !ok || got != v {
+			t.Errorf("entry %q=%q didn't round-trip, got %q", k, v, got)
+		}
+	}
+
+	existing := NewStrMap()
+	existing.Put("kept", "yes")
+	existing.Put("a", "old")
+	if err := existing.UnmarshalJSON([]byte(`{"a":"new"}`)); err != nil {
+		t.Fatalf("Unmarshal failed: %s", err)
+	}
+	if existing.GetOrDefault("kept", "") != "yes" || existing.GetOrDefault("a", "") != "new" {
+		t.Errorf("Unmarshal didn't merge into the existing entries")
+	}
+	if err := existing.UnmarshalJSON([]byte(`{"a":1}`)); err == nil {
+		t.Errorf("expected an error for a non-string value")
+	}
+}
@@ -0,0 +1,56 @@
+package hashmap
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Merge puts all entries of other into m, overwriting the values of keys
+// present in both. It's O(len(other)). The maps must be of the same type,
+// otherwise it returns ErrTypeMismatch. A nil other is a no-op.
+func (m *Map) Merge(other *Map) error {
+	if other == nil {
+		return nil
+	}
+	if m.typ != other.typ {
+		return ErrTypeMismatch
+	}
+	mapiterate(other.typ, other.hm, func(k, v unsafe.Pointer) bool {
+		runtimer.Typedmemmove(m.typ.Elem, m.assign(k), v)
+		return true
+	})
+	return nil
+}
+
+// PutAll puts all entries of src, a built-in map of the same key and value
+// types as m, into m, overwriting the values of keys present in both.
+// It returns a LoadError for a src that isn't a map and an error wrapping
+// ErrTypeMismatch if the types differ, before putting anything.
+func (m *Map) PutAll(src interface{}) error {
+	if m.typ == nil || src == nil {
+		return ErrNoType
+	}
+	rt := reflect.TypeOf(src)
+	if rt.Kind() != reflect.Map {
+		return &LoadError{Kind: rt.Kind(), Type: rt.String(), Err: ErrNotAMap}
+	}
+	if k := reflectType(m.typ.Key); rt.Key() != k {
+		return fmt.Errorf("%w: key type is %s, not %s", ErrTypeMismatch, rt.Key(), k)
+	}
+	if v := reflectType(m.typ.Elem); rt.Elem() != v {
+		return fmt.Errorf("%w: value type is %s, not %s", ErrTypeMismatch, rt.Elem(), v)
+	}
+	e := *(*emptyInterface)(unsafe.Pointer(&src))
+	t, h := (*runtimer.MapType)(unsafe.Pointer(e.typ)), (*hmap)(e.word)
+	if h == m.hm {
+		return nil
+	}
+	mapiterate(t, h, func(k, v unsafe.Pointer) bool {
+		runtimer.Typedmemmove(m.typ.Elem, m.assign(k), v)
+		return true
+	})
+	return nil
+}
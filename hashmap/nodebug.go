@@ -0,0 +1,8 @@
+//go:build !hashmapdebug
+
+package hashmap
+
+import "github.com/gramework/runtimer"
+
+// checkGrow is a no-op without the hashmapdebug build tag, see debug.go
+func checkGrow(t *runtimer.MapType, h *hmap) {}
@@ -0,0 +1,77 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Float64IMap is a map[float64]interface{}. Keys are compared as floats,
+// so NaN keys are never equal to each other: each Put of a NaN adds
+// a new entry, which can only be reached by iterating over the map.
+type Float64IMap struct {
+	hm  *hmap
+	typ *runtimer.MapType
+}
+
+var float64IMapTyp *runtimer.MapType
+
+func init() {
+	mi := interface{}(map[float64]interface{}{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	float64IMapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
+}
+
+func NewFloat64IMap(size ...int32) *Float64IMap {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
+	typ := &*float64IMapTyp
+	return &Float64IMap{
+		typ: typ,
+		hm:  makemap(typ, int64(sz), nil, nil),
+	}
+}
+
+func LoadFloat64IMap(m map[float64]interface{}) (*Float64IMap, error) {
+	if m == nil {
+		return nil, ErrNoData
+	}
+	mi := interface{}(m)
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	loadedmap := &Float64IMap{
+		typ: (*runtimer.MapType)(unsafe.Pointer(e.typ)),
+		hm:  (*hmap)(e.word),
+	}
+
+	return loadedmap, nil
+}
+
+func (m *Float64IMap) KeyType() string {
+	return m.typ.Key.String()
+}
+
+// Len returns the number of entries in the map.
+func (m *Float64IMap) Len() int {
+	return m.hm.len()
+}
+
+func (m *Float64IMap) GetPtr(key float64) unsafe.Pointer {
+	return mapaccess1(m.typ, m.hm, unsafe.Pointer(&key))
+}
+
+func (m *Float64IMap) GetPtrOk(key float64) (unsafe.Pointer, bool) {
+	return mapaccess2(m.typ, m.hm, unsafe.Pointer(&key))
+}
+
+// Has reports whether the key is present, even with a zero value
+func (m *Float64IMap) Has(key float64) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
+func (m *Float64IMap) Put(key float64, value interface{}) {
+	p := mapassign(m.typ, m.hm, unsafe.Pointer(&key))
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
+}
@@ -0,0 +1,54 @@
+package hashmap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat64IMapNaNGrowDuringIteration(t *testing.T) {
+	m := NewFloat64IMap()
+	const nans, keys = 50, 50
+	for i := 0; i < nans; i++ {
+		m.Put(math.NaN(), i)
+	}
+	for i := 0; i < keys; i++ {
+		m.Put(float64(i), keys+i)
+	}
+	if n := m.hm.count; n != nans+keys {
+		t.Fatalf("expected %d entries, got %d", nans+keys, n)
+	}
+	if _, ok := m.GetPtrOk(math.NaN()); ok {
+		t.Errorf("a NaN key was found by lookup")
+	}
+
+	seen := make(map[int]int)
+	added := 0
+	var it hiter
+	for mapiterinit(m.typ, m.hm, &it); it.key != nil; mapiternext(&it) {
+		k := *(*float64)(it.key)
+		v := (*(*interface{})(it.value)).(int)
+		if v < keys+nans {
+			if math.IsNaN(k) != (v < nans) {
+				t.Errorf("unexpected key %v for value %d", k, v)
+			}
+			seen[v]++
+		}
+		// force a few grows while iterating
+		if added < 2000 {
+			for i := 0; i < 200; i++ {
+				m.Put(float64(1000+added), 1e6+added)
+				added++
+			}
+			m.Put(math.NaN(), 1e6+added)
+			added++
+		}
+	}
+	if m.hm.B < 6 {
+		t.Fatalf("expected the map to grow during iteration, B is %d", m.hm.B)
+	}
+	for v := 0; v < nans+keys; v++ {
+		if seen[v] != 1 {
+			t.Errorf("entry with value %d returned %d times", v, seen[v])
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Int64IMap is a map[int64]interface{}. Unlike IntIMap its keys are
+// 64 bits wide on every platform, and it uses the fast64 map functions.
+type Int64IMap struct {
+	hm  *hmap
+	typ *runtimer.MapType
+}
+
+var int64IMapTyp *runtimer.MapType
+
+func init() {
+	mi := interface{}(map[int64]interface{}{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	int64IMapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
+}
+
+func NewInt64IMap(size ...int32) *Int64IMap {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
+	typ := &*int64IMapTyp
+	return &Int64IMap{
+		typ: typ,
+		hm:  makemap(typ, int64(sz), nil, nil),
+	}
+}
+
+func LoadInt64IMap(m map[int64]interface{}) (*Int64IMap, error) {
+	if m == nil {
+		return nil, ErrNoData
+	}
+	mi := interface{}(m)
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	loadedmap := &Int64IMap{
+		typ: (*runtimer.MapType)(unsafe.Pointer(e.typ)),
+		hm:  (*hmap)(e.word),
+	}
+
+	return loadedmap, nil
+}
+
+func (m *Int64IMap) KeyType() string {
+	return m.typ.Key.String()
+}
+
+// Len returns the number of entries in the map.
+func (m *Int64IMap) Len() int {
+	return m.hm.len()
+}
+
+func (m *Int64IMap) GetPtr(key int64) unsafe.Pointer {
+	return mapaccess1_fast64(m.typ, m.hm, uint64(key))
+}
+
+func (m *Int64IMap) GetPtrOk(key int64) (unsafe.Pointer, bool) {
+	return mapaccess2_fast64(m.typ, m.hm, uint64(key))
+}
+
+// Has reports whether the key is present, even with a zero value
+func (m *Int64IMap) Has(key int64) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
+func (m *Int64IMap) Put(key int64, value interface{}) {
+	p := mapassign_fast64(m.typ, m.hm, uint64(key))
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
+}
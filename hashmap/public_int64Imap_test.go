@@ -0,0 +1,49 @@
+package hashmap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInt64IMap(t *testing.T) {
+	m := NewInt64IMap()
+	keys := []int64{0, 1, -1, math.MaxInt64, math.MinInt64, 1 << 40}
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	if n := m.Len(); n != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), n)
+	}
+	for i, k := range keys {
+		p, ok := m.GetPtrOk(k)
+		if !ok || (*(*interface{})(p)).(int) != i {
+			t.Errorf("key %d: unexpected lookup result", k)
+		}
+	}
+	if _, ok := m.GetPtrOk(2); ok {
+		t.Errorf("missing key was found")
+	}
+
+	src := map[int64]interface{}{1 << 33: "big"}
+	l, err := LoadInt64IMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if v := *(*interface{})(l.GetPtr(1 << 33)); v != "big" {
+		t.Errorf("unexpected value in a loaded map: %v", v)
+	}
+	if _, err := LoadInt64IMap(nil); err != ErrNoData {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
+
+func BenchmarkInt64IMapGet_65536(b *testing.B) {
+	m := NewInt64IMap()
+	for i := int64(0); i < 65536; i++ {
+		m.Put(i, nil)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.GetPtr(int64(i & 65535))
+	}
+}
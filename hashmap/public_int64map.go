@@ -0,0 +1,61 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Int64Map is a map[int64]int64 for counters and other numeric values,
+// which are stored and returned without boxing them in interfaces.
+// It uses the fast64 map functions.
+type Int64Map struct {
+	hm  *hmap
+	typ *runtimer.MapType
+}
+
+var int64MapTyp *runtimer.MapType
+
+func init() {
+	mi := interface{}(map[int64]int64{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	int64MapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
+}
+
+func NewInt64Map(size ...int32) *Int64Map {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
+	typ := &*int64MapTyp
+	return &Int64Map{
+		typ: typ,
+		hm:  makemap(typ, int64(sz), nil, nil),
+	}
+}
+
+// Len returns the number of entries in the map.
+func (m *Int64Map) Len() int {
+	return m.hm.len()
+}
+
+func (m *Int64Map) Get(key int64) (int64, bool) {
+	p, ok := mapaccess2_fast64(m.typ, m.hm, uint64(key))
+	return *(*int64)(p), ok
+}
+
+func (m *Int64Map) Put(key, value int64) {
+	*(*int64)(mapassign_fast64(m.typ, m.hm, uint64(key))) = value
+}
+
+// Add adds delta to the value of the key, which starts at 0 if the key
+// is absent, and returns the sum.
+func (m *Int64Map) Add(key, delta int64) int64 {
+	p := (*int64)(mapassign_fast64(m.typ, m.hm, uint64(key)))
+	*p += delta
+	return *p
+}
+
+func (m *Int64Map) Delete(key int64) {
+	mapdelete_fast64(m.typ, m.hm, uint64(key))
+}
@@ -0,0 +1,43 @@
+package hashmap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInt64Map(t *testing.T) {
+	m := NewInt64Map()
+	keys := []int64{0, 1, -1, math.MaxInt64, math.MinInt64, 1 << 40}
+	for i, k := range keys {
+		m.Put(k, int64(i))
+	}
+	for i, k := range keys {
+		if v, ok := m.Get(k); !ok || v != int64(i) {
+			t.Errorf("key %d: got %d, %v", k, v, ok)
+		}
+	}
+	if v, ok := m.Get(2); ok || v != 0 {
+		t.Errorf("missing key: got %d, %v", v, ok)
+	}
+	if v := m.Add(2, 5); v != 5 {
+		t.Errorf("Add to a missing key returned %d", v)
+	}
+	if v := m.Add(2, -3); v != 2 {
+		t.Errorf("Add returned %d", v)
+	}
+	m.Delete(2)
+	m.Delete(math.MinInt64)
+	if _, ok := m.Get(2); ok {
+		t.Errorf("deleted key was found")
+	}
+	if n := m.Len(); n != len(keys)-1 {
+		t.Errorf("expected %d entries, got %d", len(keys)-1, n)
+	}
+}
+
+func BenchmarkInt64MapAdd_65536(b *testing.B) {
+	m := NewInt64Map()
+	for i := 0; i < b.N; i++ {
+		m.Add(int64(i&65535), 1)
+	}
+}
@@ -49,6 +49,11 @@ func (m *IntIMap) KeyType() string {
 	return m.typ.Key.String()
 }
 
+// Len returns the number of entries in the map.
+func (m *IntIMap) Len() int {
+	return m.hm.len()
+}
+
 func (m *IntIMap) GetPtr(key int) unsafe.Pointer {
 	return mapaccess1(m.typ, m.hm, unsafe.Pointer(&key))
 }
@@ -57,7 +62,36 @@ func (m *IntIMap) GetPtrOk(key int) (unsafe.Pointer, bool) {
 	return mapaccess2(m.typ, m.hm, unsafe.Pointer(&key))
 }
 
+// Has reports whether the key is present, even with a zero value
+func (m *IntIMap) Has(key int) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
+// Get returns a copy of the value stored for the key, or nil, false
+func (m *IntIMap) Get(key int) (interface{}, bool) {
+	p, ok := mapaccess2(m.typ, m.hm, unsafe.Pointer(&key))
+	if !ok {
+		return nil, false
+	}
+	return valueAt(reflectType(m.typ.Elem), p), true
+}
+
 func (m *IntIMap) Put(key int, value interface{}) {
 	p := mapassign(m.typ, m.hm, unsafe.Pointer(&key))
-	runtimer.Typedmemmove(m.typ.Elem, p, *runtimer.EfaceDataPtr(&value))
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
+}
+
+func (m *IntIMap) Delete(key int) {
+	mapdelete(m.typ, m.hm, unsafe.Pointer(&key))
+}
+
+// RangePtr calls fn with each key and a pointer to its value slot, which
+// holds an interface{}, until fn returns false. Nothing is copied or boxed.
+// The pointer is only valid during the call: a Put may grow the map and
+// move the values, so fn must not keep it.
+func (m *IntIMap) RangePtr(fn func(key int, val unsafe.Pointer) bool) {
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		return fn(*(*int)(k), v)
+	})
 }
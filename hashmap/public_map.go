@@ -2,6 +2,7 @@ package hashmap
 
 import (
 	"errors"
+	"reflect"
 	"unsafe" // #nosec
 
 	"github.com/gramework/runtimer"
@@ -10,10 +11,51 @@ import (
 var ErrNoType = errors.New("no type can be loaded")
 var ErrNoData = errors.New("nil map, no data can be loaded")
 var ErrNotAMap = errors.New("map should be passed by value to LoadMap()")
+var ErrKeyExists = errors.New("key already exists")
+var ErrTypeMismatch = errors.New("map key or value type mismatch")
+var ErrKeyTooLong = errors.New("key is too long")
+var ErrValueTooLong = errors.New("value is too long")
+var ErrMalformedText = errors.New("malformed key=value line")
+var ErrNoVersions = errors.New("versions aren't tracked, see WithVersions")
+
+// LoadError is returned by LoadMap for a value that isn't a map,
+// with the kind and the name of its type. It wraps ErrNotAMap.
+type LoadError struct {
+	Kind reflect.Kind
+	Type string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return "expected map, got " + e.Kind.String() + " " + e.Type + ": " + e.Err.Error()
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
 
 type Map struct {
 	hm  *hmap
 	typ *runtimer.MapType
+
+	// ikey is the key type if it's an interface type. Those keys
+	// are hashed with their dynamic type, so the whole interface value
+	// is passed to the map functions instead of its data.
+	ikey reflect.Type
+	// ielem is the value type if it's an interface type,
+	// the stored values are whole interface values as well.
+	ielem reflect.Type
+
+	pins int // # of active Pin calls
+
+	aliased bool // hm belongs to the built-in map passed to LoadMap
+
+	grows uint64 // # of grows started by Put, GetOrPut, PutPtr, Merge and GrowNow
+
+	maxChain   int // see SetMaxOverflowChain
+	chainLimit int // chain length that triggers the next rehash
+
+	fn *mapFuncs // see funcs
 }
 
 func LoadMap(m interface{}) (*Map, error) {
@@ -21,34 +63,207 @@ func LoadMap(m interface{}) (*Map, error) {
 	if e.typ == nil {
 		return nil, ErrNoType
 	}
+	rt := reflect.TypeOf(m)
+	if rt.Kind() != reflect.Map {
+		return nil, &LoadError{Kind: rt.Kind(), Type: rt.String(), Err: ErrNotAMap}
+	}
 	if e.word == nil {
 		return nil, ErrNoData
 	}
 
-	if (*runtimer.MapType)(unsafe.Pointer(e.typ)).Key.Alg.Hash == nil {
-		return nil, ErrNotAMap
-	}
 	loadedmap := &Map{
 		typ: (*runtimer.MapType)(unsafe.Pointer(e.typ)),
 		hm:  (*hmap)(e.word),
+
+		aliased: true,
+	}
+	if rt.Key().Kind() == reflect.Interface {
+		loadedmap.ikey = rt.Key()
+	}
+	if rt.Elem().Kind() == reflect.Interface {
+		loadedmap.ielem = rt.Elem()
 	}
 
 	return loadedmap, nil
 }
 
+// keyPtr returns a pointer to the key as the map functions expect it
+func (m *Map) keyPtr(key interface{}) unsafe.Pointer {
+	return dataPtr(m.typ.Key, m.ikey, key)
+}
+
+// dataPtr returns a pointer to v stored as a value of type t,
+// iface is t if it's an interface type. Like funcs, it tolerates
+// the nil type of a zero Map.
+func dataPtr(t *runtimer.Type, iface reflect.Type, v interface{}) unsafe.Pointer {
+	switch {
+	case iface == nil && t != nil && t.Kind&kindDirectIface != 0:
+		// the interface holds the value itself, not a pointer to it
+		return unsafe.Pointer(&(*emptyInterface)(unsafe.Pointer(&v)).word)
+	case iface == nil:
+		return runtimer.GetEfaceDataPtr(v)
+	case iface.NumMethod() == 0:
+		return unsafe.Pointer(&v)
+	}
+	p := reflect.New(iface)
+	if v != nil {
+		p.Elem().Set(reflect.ValueOf(v))
+	}
+	return p.UnsafePointer()
+}
+
 func (m *Map) KeyType() string {
 	return m.typ.Key.String()
 }
 
+// Len returns the number of entries in the map.
+func (m *Map) Len() int {
+	return m.hm.len()
+}
+
+// IsAliased reports whether m shares its data with the built-in map
+// it was loaded from, so changes through either are visible in both.
+// Maps returned by Clone, and m after Clear, have their own data.
+func (m *Map) IsAliased() bool {
+	return m.aliased
+}
+
 func (m *Map) GetPtr(key interface{}) unsafe.Pointer {
-	return mapaccess1(m.typ, m.hm, runtimer.GetEfaceDataPtr(key))
+	return m.funcs().access1(m.typ, m.hm, m.keyPtr(key))
 }
 
 func (m *Map) GetPtrOk(key interface{}) (unsafe.Pointer, bool) {
-	return mapaccess2(m.typ, m.hm, runtimer.GetEfaceDataPtr(key))
+	return m.funcs().access2(m.typ, m.hm, m.keyPtr(key))
+}
+
+// Has reports whether the key is present, even with a zero value
+func (m *Map) Has(key interface{}) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
 }
 
 func (m *Map) Put(key, value interface{}) {
-	p := mapassign(m.typ, m.hm, runtimer.GetEfaceDataPtr(key))
-	runtimer.Typedmemmove(m.typ.Elem, p, runtimer.GetEfaceDataPtr(value))
+	p := m.assign(m.keyPtr(key))
+	runtimer.Typedmemmove(m.typ.Elem, p, dataPtr(m.typ.Elem, m.ielem, value))
+}
+
+// GetOrPut returns a copy of the value stored for the key and true, or
+// stores value and returns it and false if there's none. It takes a single
+// mapassign, which tells a new entry by the count going up. Like the rest
+// of Map it's not safe for concurrent use, see ConcurrentMap.GetOrPut.
+func (m *Map) GetOrPut(key, value interface{}) (actual interface{}, loaded bool) {
+	n := m.hm.len()
+	p := m.assign(m.keyPtr(key))
+	if m.hm.count == n {
+		return valueAt(reflectType(m.typ.Elem), p), true
+	}
+	runtimer.Typedmemmove(m.typ.Elem, p, dataPtr(m.typ.Elem, m.ielem, value))
+	return value, false
+}
+
+// PutIfAbsent stores value for the key and returns true if the key is
+// missing, and otherwise returns false leaving the old value untouched.
+// Like GetOrPut, it takes a single mapassign.
+func (m *Map) PutIfAbsent(key, value interface{}) bool {
+	n := m.hm.len()
+	p := m.assign(m.keyPtr(key))
+	if m.hm.count == n {
+		return false
+	}
+	runtimer.Typedmemmove(m.typ.Elem, p, dataPtr(m.typ.Elem, m.ielem, value))
+	return true
+}
+
+// PutPtr stores valuePtr itself as the value of the key, without boxing it
+// in an interface. It's for maps of pointer values, such as map[string]*T,
+// and panics with ErrTypeMismatch for other maps. valuePtr must point to
+// a value of the map's pointer element type, e.g. a *T converted to
+// unsafe.Pointer, or be nil.
+func (m *Map) PutPtr(key interface{}, valuePtr unsafe.Pointer) {
+	m.mustHavePtrValues()
+	p := m.assign(m.keyPtr(key))
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&valuePtr))
+}
+
+// GetRawPtr returns the pointer stored as the value of the key, or nil
+// if there's none. Like PutPtr, it's for maps of pointer values only.
+func (m *Map) GetRawPtr(key interface{}) unsafe.Pointer {
+	m.mustHavePtrValues()
+	return *(*unsafe.Pointer)(m.GetPtr(key))
+}
+
+func (m *Map) mustHavePtrValues() {
+	if k := m.typ.Elem.Kind & kindMask; k != kindPtr && k != kindUnsafePointer {
+		panic(ErrTypeMismatch)
+	}
+}
+
+func (m *Map) Delete(key interface{}) {
+	m.funcs().delete(m.typ, m.hm, m.keyPtr(key))
+}
+
+// Range calls fn for each entry until fn returns false. The keys and values
+// are copies. As with a built-in map, the order is unspecified, entries put
+// during Range may or may not be visited and deleted ones aren't visited.
+func (m *Map) Range(fn func(key, value interface{}) bool) {
+	if m.hm.len() == 0 {
+		return
+	}
+	kt, vt := reflectType(m.typ.Key), reflectType(m.typ.Elem)
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		return fn(valueAt(kt, k), valueAt(vt, v))
+	})
+}
+
+// Keys returns copies of all keys in unspecified order.
+// It never returns nil.
+func (m *Map) Keys() []interface{} {
+	keys := make([]interface{}, 0, m.hm.len())
+	m.Range(func(key, _ interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (m *Map) sameTypes(typ *runtimer.MapType) bool {
+	return m.typ.Key == typ.Key && m.typ.Elem == typ.Elem
+}
+
+// AsStrMap returns a StrMap sharing storage with m,
+// or ErrTypeMismatch if m is not a map[string]string.
+func (m *Map) AsStrMap() (*StrMap, error) {
+	if !m.sameTypes(strMapTyp) {
+		return nil, ErrTypeMismatch
+	}
+	sm := &StrMap{
+		typ: m.typ,
+		hm:  m.hm,
+	}
+	sm.keyBytes = sm.scanKeyBytes()
+	return sm, nil
+}
+
+// AsStrIMap returns a StrIMap sharing storage with m,
+// or ErrTypeMismatch if m is not a map[string]interface{}.
+func (m *Map) AsStrIMap() (*StrIMap, error) {
+	if !m.sameTypes(strIMapTyp) {
+		return nil, ErrTypeMismatch
+	}
+	return &StrIMap{
+		typ: m.typ,
+		hm:  m.hm,
+	}, nil
+}
+
+// AsIntIMap returns an IntIMap sharing storage with m,
+// or ErrTypeMismatch if m is not a map[int]interface{}.
+func (m *Map) AsIntIMap() (*IntIMap, error) {
+	if !m.sameTypes(intIMapTyp) {
+		return nil, ErrTypeMismatch
+	}
+	return &IntIMap{
+		typ: m.typ,
+		hm:  m.hm,
+	}, nil
 }
@@ -0,0 +1,1030 @@
+package hashmap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+func TestMapEstimateCardinality(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if n := m.EstimateCardinality(16); n != 0 {
+		t.Errorf("expected 0 for an empty map, got %d", n)
+	}
+	const keys = 100000
+	for i := 0; i < keys; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 10; i++ {
+		n := m.EstimateCardinality(512)
+		if n < keys*9/10 || n > keys*11/10 {
+			t.Errorf("estimate %d is more than 10%% off %d", n, keys)
+		}
+	}
+	if n := m.EstimateCardinality(1 << 30); n != keys {
+		t.Errorf("sampling every bucket should give the exact count, got %d", n)
+	}
+}
+
+func TestMapDowncast(t *testing.T) {
+	native := map[string]string{"a": "1"}
+	m, err := LoadMap(native)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	sm, err := m.AsStrMap()
+	if err != nil {
+		t.Fatalf("AsStrMap failed: %s", err)
+	}
+	sm.Put("b", "2")
+	if native["b"] != "2" {
+		t.Errorf("StrMap doesn't share storage with the Map")
+	}
+	if v := *(*string)(m.GetPtr("b")); v != "2" {
+		t.Errorf("Map doesn't see the StrMap write, got %q", v)
+	}
+	if _, err := m.AsStrIMap(); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch from AsStrIMap, got %v", err)
+	}
+	if _, err := m.AsIntIMap(); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch from AsIntIMap, got %v", err)
+	}
+
+	im, err := LoadMap(map[int]interface{}{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if _, err := im.AsIntIMap(); err != nil {
+		t.Errorf("AsIntIMap failed: %s", err)
+	}
+	if _, err := im.AsStrMap(); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch from AsStrMap, got %v", err)
+	}
+
+	sim, err := LoadMap(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if _, err := sim.AsStrIMap(); err != nil {
+		t.Errorf("AsStrIMap failed: %s", err)
+	}
+}
+
+func TestMapInterfaceKeys(t *testing.T) {
+	m, err := LoadMap(map[interface{}]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	m.Put(1, 10)
+	m.Put("1", 20)
+	m.Put(int64(1), 30)
+	m.Put(1, 11)
+
+	for _, c := range []struct {
+		key   interface{}
+		value int
+	}{{1, 11}, {"1", 20}, {int64(1), 30}} {
+		p, ok := m.GetPtrOk(c.key)
+		if !ok {
+			t.Errorf("key %#v not found", c.key)
+			continue
+		}
+		if v := *(*int)(p); v != c.value {
+			t.Errorf("expected %d by key %#v, got %d", c.value, c.key, v)
+		}
+	}
+	if _, ok := m.GetPtrOk(uint(1)); ok {
+		t.Errorf("uint(1) collided with a key of another type")
+	}
+	if n := m.hm.count; n != 3 {
+		t.Errorf("expected 3 entries, got %d", n)
+	}
+}
+
+func TestMapWouldGrow(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	// a single bucket map grows on inserting the 9th key
+	for i := 0; i < bucketCnt; i++ {
+		if m.WouldGrow() {
+			t.Fatalf("WouldGrow is true with %d keys", i)
+		}
+		m.Put(i, i)
+	}
+	if !m.WouldGrow() {
+		t.Fatalf("WouldGrow is false with %d keys", bucketCnt)
+	}
+	m.Put(0, 1) // replacing a key doesn't grow
+	if m.hm.growing() {
+		t.Fatalf("replacing a key started growing")
+	}
+	m.Put(bucketCnt, bucketCnt)
+	if !m.hm.growing() && m.hm.B == 0 {
+		t.Errorf("the map didn't grow after WouldGrow reported true")
+	}
+}
+
+// constHashType returns the type of map[int]int with a key hash of 0,
+// so every key lands in the same bucket
+func constHashType(t *testing.T) *runtimer.MapType {
+	return hashType(t, func(int, uintptr) uintptr { return 0 })
+}
+
+// hashType returns the type of map[int]int with the given key hash
+func hashType(t *testing.T, hash func(key int, seed uintptr) uintptr) *runtimer.MapType {
+	base, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	alg := *base.typ.Key.Alg
+	alg.Hash = func(p unsafe.Pointer, seed uintptr) uintptr { return hash(*(*int)(p), seed) }
+	key := *base.typ.Key
+	key.Alg = &alg
+	mt := *base.typ
+	mt.Key = &key
+	return &mt
+}
+
+// longestChain returns the longest overflow chain of m
+func longestChain(m *Map) int {
+	longest := 0
+	for i := 0; ; i++ {
+		cells := m.DumpBucket(i)
+		if cells == nil {
+			return longest
+		}
+		if n := cells[len(cells)-1].Overflow; n > longest {
+			longest = n
+		}
+	}
+}
+
+func TestMapMaxOverflowChain(t *testing.T) {
+	// every key collides under the seed 0, and no key under the others
+	mt := hashType(t, func(key int, seed uintptr) uintptr {
+		if seed == 0 {
+			return 0
+		}
+		return uintptr(key) * (seed | 1)
+	})
+	for _, max := range []int{0, 4} {
+		m := &Map{typ: mt, hm: makemap(mt, 1000, nil, nil)}
+		m.hm.hash0 = 0
+		m.SetMaxOverflowChain(max)
+		for i := 0; i < 5*bucketCnt; i++ {
+			m.Put(i, i)
+		}
+		if n := m.GrowCount(); n != 0 {
+			t.Fatalf("max=%d: the map grew with a chain of 4", max)
+		}
+		m.Put(5*bucketCnt, 5*bucketCnt)
+		if rehashed := m.GrowCount() == 1; rehashed != (max > 0) {
+			t.Errorf("max=%d: rehashed is %v after the chain got longer", max, rehashed)
+		}
+		if n := longestChain(m); max > 0 && n > max {
+			t.Errorf("max=%d: a chain of %d is left after the rehash", max, n)
+		}
+		for i := 0; i <= 5*bucketCnt; i++ {
+			if p, ok := m.GetPtrOk(i); !ok || *(*int)(p) != i {
+				t.Errorf("max=%d: key %d lost", max, i)
+			}
+		}
+	}
+}
+
+func TestMapMaxOverflowChainSettles(t *testing.T) {
+	// a new seed doesn't split these keys, so rehashing can't help
+	m := &Map{typ: constHashType(t)}
+	m.hm = makemap(m.typ, 1000, nil, nil)
+	m.SetMaxOverflowChain(4)
+	for i := 0; i <= 5*bucketCnt; i++ {
+		m.Put(i, i)
+	}
+	if n := m.GrowCount(); n != 1 {
+		t.Fatalf("expected a rehash at a chain of 5, got %d grows", n)
+	}
+	// the next rehash waits for a chain of 5+4
+	for i := 5*bucketCnt + 1; i < 6*bucketCnt; i++ {
+		m.Put(i, i)
+	}
+	if n := m.GrowCount(); n != 1 {
+		t.Errorf("the map was rehashed again with the same chain, %d grows", n)
+	}
+	if m.chainLimit != 5+4 {
+		t.Errorf("expected the next rehash at a chain of 9, not %d", m.chainLimit)
+	}
+}
+
+func TestMapMaxOverflowChainNaN(t *testing.T) {
+	base, err := LoadMap(map[float64]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	// every key collides under the seed 0, NaNs too
+	alg := *base.typ.Key.Alg
+	alg.Hash = func(p unsafe.Pointer, seed uintptr) uintptr {
+		if seed == 0 {
+			return 0
+		}
+		return uintptr(*(*float64)(p)) * seed
+	}
+	key := *base.typ.Key
+	key.Alg = &alg
+	mt := *base.typ
+	mt.Key = &key
+	m := &Map{typ: &mt, hm: makemap(&mt, 1000, nil, nil)}
+	m.hm.hash0 = 0
+	m.SetMaxOverflowChain(4)
+	for i := 0; i < 5*bucketCnt; i++ {
+		m.Put(float64(i), i)
+	}
+	// the NaN adds the fifth overflow bucket, but can't be found after a rehash
+	m.Put(math.NaN(), 7)
+	if n := m.GrowCount(); n != 0 {
+		t.Errorf("the map was rehashed for a NaN")
+	}
+	if p := m.GetPtr(-1.0); *(*int)(p) != 0 {
+		t.Fatalf("a miss reads %d after putting a NaN", *(*int)(p))
+	}
+	// these fill the last overflow bucket and add a sixth
+	for i := 5 * bucketCnt; i <= 6*bucketCnt; i++ {
+		m.Put(float64(i), i)
+	}
+	if m.GrowCount() == 0 {
+		t.Fatalf("the map wasn't rehashed after the NaN")
+	}
+	if p := m.GetPtr(-1.0); *(*int)(p) != 0 {
+		t.Errorf("a miss reads %d after the rehash", *(*int)(p))
+	}
+	nans := 0
+	m.Range(func(k, v interface{}) bool {
+		if f := k.(float64); f != f {
+			nans++
+			if v.(int) != 7 {
+				t.Errorf("the NaN holds %d", v)
+			}
+		}
+		return true
+	})
+	if nans != 1 || m.Len() != 6*bucketCnt+2 {
+		t.Errorf("expected %d keys and a NaN, got %d and %d", 6*bucketCnt+2, m.Len(), nans)
+	}
+}
+
+func TestMapGrowNow(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	for m.hm.growing() {
+		m.Put(0, 0)
+	}
+	m.GrowNow()
+	if m.hm.oldbuckets == nil {
+		t.Fatalf("GrowNow didn't start growing")
+	}
+	for i := 100; i < 200; i++ {
+		m.Put(i, i)
+	}
+	if m.hm.growing() {
+		t.Errorf("inserts didn't complete the evacuation")
+	}
+	for i := 0; i < 200; i++ {
+		if p, ok := m.GetPtrOk(i); !ok || *(*int)(p) != i {
+			t.Errorf("key %d lost after GrowNow", i)
+		}
+	}
+}
+
+func TestMapPin(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	unpin := m.Pin()
+	if m.hm.growing() {
+		t.Fatalf("Pin didn't complete the grow in progress")
+	}
+	p := m.GetPtr(5)
+	B := m.hm.B
+	for i := 100; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	if m.hm.B != B || m.hm.growing() {
+		t.Fatalf("the map grew while pinned")
+	}
+	if m.GetPtr(5) != p || *(*int)(p) != 5 {
+		t.Fatalf("value pointer changed while pinned")
+	}
+	m.Put(5, 42)
+	if *(*int)(p) != 42 {
+		t.Errorf("replacing a value while pinned didn't update it in place")
+	}
+	unpin()
+	unpin()
+	if m.pins != 0 {
+		t.Fatalf("second unpin call wasn't ignored")
+	}
+	m.Put(10000, 10000)
+	if !m.hm.growing() && m.hm.B == B {
+		t.Errorf("the map didn't grow after unpin")
+	}
+	for i := 0; i < 10001; i++ {
+		if p, ok := m.GetPtrOk(i); !ok || (i != 5 && *(*int)(p) != i) {
+			t.Errorf("key %d lost", i)
+		}
+	}
+}
+
+func TestMapFastPaths(t *testing.T) {
+	for _, c := range []struct {
+		native interface{}
+		key    interface{}
+		funcs  *mapFuncs
+	}{
+		{map[int32]int{}, int32(-1), &fast32Funcs},
+		{map[uint64]int{}, uint64(1 << 63), &fast64Funcs},
+		{map[int]int{}, -1, &fast64Funcs},
+		{map[string]int{}, "key", &faststrFuncs},
+		{map[float64]int{}, 1.5, &genericFuncs},
+		{map[int][200]byte{}, 1, &genericFuncs},
+	} {
+		m, err := LoadMap(c.native)
+		if err != nil {
+			t.Fatalf("Can't load map: %s", err)
+		}
+		if m.funcs() != c.funcs && !(c.funcs == &fast64Funcs && m.typ.Key.Size == 4) {
+			t.Errorf("%T: unexpected access functions selected", c.native)
+		}
+		if _, ok := m.GetPtrOk(c.key); ok {
+			t.Errorf("%T: key found in an empty map", c.native)
+		}
+		if _, ok := c.native.(map[int][200]byte); ok {
+			continue
+		}
+		m.Put(c.key, 42)
+		if p, ok := m.GetPtrOk(c.key); !ok || *(*int)(p) != 42 {
+			t.Errorf("%T: key not found after Put", c.native)
+		}
+		m.Delete(c.key)
+		if _, ok := m.GetPtrOk(c.key); ok {
+			t.Errorf("%T: key found after Delete", c.native)
+		}
+	}
+}
+
+func TestMapLen(t *testing.T) {
+	if n := (&Map{}).Len(); n != 0 {
+		t.Errorf("zero Map has Len %d", n)
+	}
+	m, err := LoadMap(map[int]int{1: 1, 2: 2})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if n := m.Len(); n != 2 {
+		t.Errorf("expected Len 2, got %d", n)
+	}
+	m.Put(3, 3)
+	m.Put(1, 0)
+	m.Delete(2)
+	if n := m.Len(); n != 2 {
+		t.Errorf("expected Len 2 after Put and Delete, got %d", n)
+	}
+
+	s := NewStrMap()
+	s.Put("a", "")
+	s.Delete("a")
+	if n := s.Len(); n != 0 {
+		t.Errorf("expected StrMap Len 0 after Delete, got %d", n)
+	}
+	if n := NewIntIMap().Len(); n != 0 {
+		t.Errorf("expected IntIMap Len 0, got %d", n)
+	}
+}
+
+func TestMapRange(t *testing.T) {
+	(&Map{}).Range(func(key, value interface{}) bool {
+		t.Errorf("callback invoked on a zero Map")
+		return true
+	})
+
+	src, want := map[string]interface{}{}, map[string]int{}
+	for i := 0; i < 100; i++ {
+		src[fmt.Sprint(i)] = i
+		want[fmt.Sprint(i)] = i
+	}
+	m, err := LoadMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	m.GrowNow()
+	if !m.hm.growing() {
+		t.Fatalf("GrowNow didn't start growing")
+	}
+	seen := map[string]bool{}
+	m.Range(func(key, value interface{}) bool {
+		k := key.(string)
+		if seen[k] {
+			t.Errorf("key %q visited twice", k)
+		}
+		seen[k] = true
+		if value != want[k] {
+			t.Errorf("unexpected value for %q: %v", k, value)
+		}
+		return true
+	})
+	if len(seen) != len(want) {
+		t.Errorf("expected %d keys, visited %d", len(want), len(seen))
+	}
+
+	calls := 0
+	m.Range(func(key, value interface{}) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Range didn't stop, %d calls", calls)
+	}
+}
+
+func TestMapKeys(t *testing.T) {
+	if keys := (&Map{}).Keys(); keys == nil || len(keys) != 0 {
+		t.Errorf("zero Map returned %#v", keys)
+	}
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	m.GrowNow()
+	m.Delete(0)
+	keys := m.Keys()
+	if len(keys) != 99 {
+		t.Fatalf("expected 99 keys, got %d", len(keys))
+	}
+	seen := map[int]bool{}
+	for _, k := range keys {
+		if i := k.(int); seen[i] || i == 0 {
+			t.Errorf("unexpected key %d", i)
+		} else {
+			seen[i] = true
+		}
+	}
+}
+
+func TestMapClone(t *testing.T) {
+	if c := (&Map{}).Clone(); c.Len() != 0 {
+		t.Errorf("clone of a zero Map has %d entries", c.Len())
+	}
+	m, err := LoadMap(map[string][]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	shared := []int{0}
+	for i := 0; i < 100; i++ {
+		m.Put(fmt.Sprint(i), shared)
+	}
+	c := m.Clone()
+	m.Delete("0")
+	m.Put("new", shared)
+	if c.Len() != 100 {
+		t.Fatalf("expected 100 entries in the clone, got %d", c.Len())
+	}
+	if _, ok := c.GetPtrOk("new"); ok {
+		t.Errorf("a key put into the original appeared in the clone")
+	}
+	p, ok := c.GetPtrOk("0")
+	if !ok {
+		t.Fatalf("a key deleted from the original disappeared from the clone")
+	}
+	if v := *(*[]int)(p); &v[0] != &shared[0] {
+		t.Errorf("the values don't alias")
+	}
+
+	empty, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	c = empty.Clone()
+	c.Put(1, 1)
+	if empty.Len() != 0 || c.Len() != 1 {
+		t.Errorf("the clone of an empty map isn't independent")
+	}
+}
+
+func TestMapMerge(t *testing.T) {
+	m, err := LoadMap(map[int]int{1: 1, 2: 2})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	other, err := LoadMap(map[int]int{2: 20, 3: 30})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if err := m.Merge(other); err != nil {
+		t.Fatalf("Merge failed: %s", err)
+	}
+	for k, v := range map[int]int{1: 1, 2: 20, 3: 30} {
+		if p, ok := m.GetPtrOk(k); !ok || *(*int)(p) != v {
+			t.Errorf("expected %d by %d after Merge", v, k)
+		}
+	}
+	if m.Len() != 3 || other.Len() != 2 {
+		t.Errorf("unexpected lengths after Merge: %d, %d", m.Len(), other.Len())
+	}
+
+	if err := m.Merge(nil); err != nil {
+		t.Errorf("Merge(nil) returned %s", err)
+	}
+	if err := m.Merge(&Map{}); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch for a zero Map, got %v", err)
+	}
+	strs, err := LoadMap(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if err := m.Merge(strs); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch, got %v", err)
+	}
+	empty, err := LoadMap(map[string]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if err := m.Merge(empty); err != ErrTypeMismatch {
+		t.Errorf("expected ErrTypeMismatch for an empty map, got %v", err)
+	}
+}
+
+func TestMapPutAll(t *testing.T) {
+	m, err := LoadMap(map[string]interface{}{"a": 1})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if err := m.PutAll(map[string]interface{}{"a": 10, "b": "two", "c": nil}); err != nil {
+		t.Fatalf("PutAll failed: %s", err)
+	}
+	for k, v := range map[string]interface{}{"a": 10, "b": "two", "c": nil} {
+		if p, ok := m.GetPtrOk(k); !ok || *(*interface{})(p) != v {
+			t.Errorf("expected %v by %s after PutAll", v, k)
+		}
+	}
+	if err := m.PutAll(map[string]int{"d": 4}); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %v", err)
+	}
+	if err := m.PutAll([]string{"d"}); !errors.Is(err, ErrNotAMap) {
+		t.Errorf("expected ErrNotAMap, got %v", err)
+	}
+	if err := m.PutAll(map[string]interface{}(nil)); err != nil {
+		t.Errorf("PutAll of a nil map returned %s", err)
+	}
+	if m.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", m.Len())
+	}
+}
+
+func TestMapClear(t *testing.T) {
+	src := map[int]int{1: 1}
+	m, err := LoadMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	unpin := m.Pin()
+	m.Clear()
+	if n := m.Len(); n != 0 {
+		t.Errorf("expected no entries after Clear, got %d", n)
+	}
+	if len(src) != 100 {
+		t.Errorf("Clear changed the loaded map, it has %d entries", len(src))
+	}
+	for i := 0; i < 2*bucketCnt; i++ {
+		m.Put(i, -i)
+	}
+	if m.hm.B != 0 {
+		t.Errorf("a pinned map grew after Clear")
+	}
+	unpin()
+	m.Put(-1, 1)
+	if !m.hm.growing() && m.hm.B == 0 {
+		t.Errorf("unpin didn't enable growing after Clear")
+	}
+	if p, ok := m.GetPtrOk(3); !ok || *(*int)(p) != -3 {
+		t.Errorf("Put after Clear didn't work")
+	}
+	(&Map{}).Clear()
+}
+
+func TestMapDumpBucket(t *testing.T) {
+	mt := constHashType(t)
+	if (&Map{}).DumpBucket(0) != nil {
+		t.Errorf("dumped a bucket of a zero Map")
+	}
+	// large enough not to grow
+	m := &Map{typ: mt, hm: makemap(mt, 1000, nil, nil)}
+	const keys = bucketCnt + 2
+	for i := 0; i < keys; i++ {
+		m.Put(i*10, i)
+	}
+	m.Delete(10)
+	cells := m.DumpBucket(0)
+	if len(cells) != 2*bucketCnt {
+		t.Fatalf("expected %d cells, got %d", 2*bucketCnt, len(cells))
+	}
+	for i, c := range cells {
+		occupied := i < keys && i != 1
+		if c.Overflow != i/bucketCnt || c.Cell != i%bucketCnt || c.Occupied != occupied {
+			t.Errorf("unexpected cell #%d: %+v", i, c)
+			continue
+		}
+		if occupied && (c.Key != fmt.Sprint(i*10) || c.Tophash != minTopHash) {
+			t.Errorf("unexpected occupied cell #%d: %+v", i, c)
+		}
+		if !occupied && (c.Key != "" || c.Tophash != empty) {
+			t.Errorf("unexpected empty cell #%d: %+v", i, c)
+		}
+	}
+	if m.DumpBucket(1<<m.hm.B) != nil || m.DumpBucket(-1) != nil {
+		t.Errorf("dumped a bucket out of range")
+	}
+}
+
+func TestMapIsAliased(t *testing.T) {
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if !m.IsAliased() {
+		t.Errorf("a loaded map isn't aliased")
+	}
+	if m.Clone().IsAliased() {
+		t.Errorf("a clone is aliased")
+	}
+	m.Clear()
+	if m.IsAliased() {
+		t.Errorf("a cleared map is still aliased")
+	}
+	if (&Map{}).IsAliased() {
+		t.Errorf("a zero Map is aliased")
+	}
+}
+
+func TestMapPutValueKinds(t *testing.T) {
+	ptrs, err := LoadMap(map[string]*int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	n := 42
+	ptrs.Put("p", &n)
+	if p, ok := ptrs.GetPtrOk("p"); !ok || *(**int)(p) != &n {
+		t.Errorf("a pointer value didn't round-trip")
+	}
+
+	ifaces, err := LoadMap(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	ifaces.Put("s", "str")
+	ifaces.Put("p", &n)
+	ifaces.Put("nil", nil)
+	for key, want := range map[string]interface{}{"s": "str", "p": &n, "nil": nil} {
+		if p, ok := ifaces.GetPtrOk(key); !ok || *(*interface{})(p) != want {
+			t.Errorf("interface value by %s didn't round-trip", key)
+		}
+	}
+
+	errs, err := LoadMap(map[int]error{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	errs.Put(1, ErrNoData)
+	errs.Put(2, nil)
+	if p, ok := errs.GetPtrOk(1); !ok || *(*error)(p) != ErrNoData {
+		t.Errorf("an error value didn't round-trip")
+	}
+	if p, ok := errs.GetPtrOk(2); !ok || *(*error)(p) != nil {
+		t.Errorf("a nil error value didn't round-trip")
+	}
+
+	keys, err := LoadMap(map[*int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	keys.Put(&n, 1)
+	if p, ok := keys.GetPtrOk(&n); !ok || *(*int)(p) != 1 {
+		t.Errorf("a pointer key didn't round-trip")
+	}
+	if _, ok := keys.GetPtrOk(new(int)); ok {
+		t.Errorf("found a different pointer key")
+	}
+}
+
+func TestMapPutPtr(t *testing.T) {
+	type T struct{ N int }
+	m, err := LoadMap(map[string]*T{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	v := &T{N: 1}
+	m.PutPtr("a", unsafe.Pointer(v))
+	m.PutPtr("nil", nil)
+	if p := m.GetRawPtr("a"); (*T)(p) != v {
+		t.Errorf("GetRawPtr returned %p, expected %p", p, v)
+	}
+	if p, ok := m.GetPtrOk("a"); !ok || *(**T)(p) != v {
+		t.Errorf("PutPtr stored a different pointer")
+	}
+	if p, ok := m.GetPtrOk("nil"); !ok || *(**T)(p) != nil {
+		t.Errorf("PutPtr didn't store nil")
+	}
+	if p := m.GetRawPtr("missing"); p != nil {
+		t.Errorf("GetRawPtr of a missing key returned %p", p)
+	}
+	m.Put("b", v) // the boxed way stores the same pointer
+	if p := m.GetRawPtr("b"); (*T)(p) != v {
+		t.Errorf("Put and GetRawPtr disagree")
+	}
+
+	ints, err := LoadMap(map[string]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	defer func() {
+		if r := recover(); r != ErrTypeMismatch {
+			t.Errorf("expected a panic with ErrTypeMismatch, got %v", r)
+		}
+	}()
+	ints.PutPtr("a", unsafe.Pointer(v))
+}
+
+func TestMapStrKeys(t *testing.T) {
+	m, err := LoadMap(map[string]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	long := strings.Repeat("x", 1<<10)
+	keys := []string{
+		"", "a", "ab", strings.Repeat("s", 31), strings.Repeat("s", 32), strings.Repeat("s", 33),
+		// long keys that only differ in the middle or at one end
+		long + "1" + long, long + "2" + long, "1" + long, "2" + long, long + "1", long + "2",
+	}
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	if m.Len() != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), m.Len())
+	}
+	for i, k := range keys {
+		if p, ok := m.GetPtrOk(k); !ok || *(*int)(p) != i {
+			t.Errorf("key #%d: unexpected lookup result", i)
+		}
+		// a copy with different backing memory
+		if p := m.GetPtr(string([]byte(k))); *(*int)(p) != i {
+			t.Errorf("key #%d: the copy of the key isn't found", i)
+		}
+	}
+	for _, k := range []string{"b", strings.Repeat("s", 34), long + "3" + long, long} {
+		if _, ok := m.GetPtrOk(k); ok {
+			t.Errorf("missing key of length %d found", len(k))
+		}
+	}
+	for i, k := range keys {
+		if i%2 == 0 {
+			m.Delete(k)
+		}
+	}
+	for i, k := range keys {
+		if _, ok := m.GetPtrOk(k); ok != (i%2 == 1) {
+			t.Errorf("key #%d: found is %v after deleting the even keys", i, ok)
+		}
+	}
+}
+
+func TestIntIMapGet(t *testing.T) {
+	m := NewIntIMap()
+	m.Put(1, "one")
+	m.Put(2, nil)
+	if v, ok := m.Get(1); !ok || v != "one" {
+		t.Errorf("Get(1) = %v, %v", v, ok)
+	}
+	if v, ok := m.Get(2); !ok || v != nil {
+		t.Errorf("Get of a nil value = %v, %v", v, ok)
+	}
+	if v, ok := m.Get(3); ok || v != nil {
+		t.Errorf("Get of a missing key = %v, %v", v, ok)
+	}
+}
+
+func TestIntIMapDelete(t *testing.T) {
+	m := NewIntIMap()
+	m.Put(1, "a")
+	m.Put(2, nil)
+	m.Delete(2)
+	m.Delete(3)
+	if m.Has(2) || !m.Has(1) || m.Len() != 1 {
+		t.Errorf("Delete removed the wrong keys")
+	}
+}
+
+func TestIntIMapRangePtr(t *testing.T) {
+	m := NewIntIMap()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i*2)
+	}
+	sum := 0
+	m.RangePtr(func(key int, val unsafe.Pointer) bool {
+		if v := (*(*interface{})(val)).(int); v != key*2 {
+			t.Errorf("key %d has value %d", key, v)
+		}
+		sum += key
+		return true
+	})
+	if sum != 99*100/2 {
+		t.Errorf("RangePtr didn't visit every key once")
+	}
+	visited := 0
+	m.RangePtr(func(int, unsafe.Pointer) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("RangePtr went on after fn returned false")
+	}
+}
+
+func TestHas(t *testing.T) {
+	m, err := LoadMap(map[string]int{"zero": 0})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if !m.Has("zero") || m.Has("missing") {
+		t.Errorf("Map.Has is wrong")
+	}
+	im := NewIntIMap()
+	im.Put(0, nil)
+	if !im.Has(0) || im.Has(1) {
+		t.Errorf("IntIMap.Has is wrong")
+	}
+	sm := NewStrMap()
+	sm.Put("", "")
+	if !sm.Has("") || sm.Has("missing") {
+		t.Errorf("StrMap.Has is wrong")
+	}
+	sim := NewStrIMap()
+	sim.Put("nil", nil)
+	if !sim.Has("nil") || sim.Has("missing") {
+		t.Errorf("StrIMap.Has is wrong")
+	}
+}
+
+func TestMapBytesPerEntry(t *testing.T) {
+	if n := (&Map{}).EstimatedBytes(); n != 0 {
+		t.Errorf("a zero Map takes %d bytes", n)
+	}
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if b := m.BytesPerEntry(); b <= 0 {
+		t.Errorf("an empty map takes %f bytes per entry", b)
+	}
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	// a bucket of 8 int pairs takes 144 bytes, 18 per cell,
+	// and the cells are 40% to 80% full, plus the old buckets
+	if b := m.BytesPerEntry(); b < 18 || b > 18/0.4*1.5 {
+		t.Errorf("unexpected bytes per entry: %f", b)
+	}
+	if m.EstimatedBytes() < 10000*16 {
+		t.Errorf("estimated %d bytes for 10000 entries", m.EstimatedBytes())
+	}
+}
+
+func TestLoadMapError(t *testing.T) {
+	for _, c := range []struct {
+		v    interface{}
+		kind reflect.Kind
+	}{
+		{[]int{1}, reflect.Slice},
+		{struct{}{}, reflect.Struct},
+		{&map[int]int{}, reflect.Ptr},
+	} {
+		_, err := LoadMap(c.v)
+		if !errors.Is(err, ErrNotAMap) {
+			t.Errorf("%T: expected ErrNotAMap, got %v", c.v, err)
+		}
+		var le *LoadError
+		if !errors.As(err, &le) || le.Kind != c.kind || le.Type != reflect.TypeOf(c.v).String() {
+			t.Errorf("%T: unexpected error %#v", c.v, err)
+		}
+	}
+}
+
+func TestMapPutIfAbsent(t *testing.T) {
+	m, err := LoadMap(map[string]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if !m.PutIfAbsent("a", 1) {
+		t.Errorf("PutIfAbsent of a new key returned false")
+	}
+	if m.PutIfAbsent("a", 2) {
+		t.Errorf("PutIfAbsent of an existing key returned true")
+	}
+	if v := *(*int)(m.GetPtr("a")); v != 1 {
+		t.Errorf("PutIfAbsent overwrote the value with %d", v)
+	}
+	if m.Len() != 1 {
+		t.Errorf("expected 1 entry, got %d", m.Len())
+	}
+}
+
+func TestMapStats(t *testing.T) {
+	if s := (&Map{}).Stats(); s != (MapStats{}) {
+		t.Errorf("a zero Map has stats %+v", s)
+	}
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	s := m.Stats()
+	if s.Count != 1000 || s.Buckets != 1<<s.B {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	// 1000 entries at a load factor of 6.5 need at least 154 buckets
+	if s.Buckets < 154 {
+		t.Errorf("%d buckets for 1000 entries", s.Buckets)
+	}
+}
+
+func TestMapGrowCount(t *testing.T) {
+	presized, err := LoadMap(make(map[int]int, 1000))
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	m, err := LoadMap(map[int]int{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 1000; i++ {
+		presized.Put(i, i)
+		m.Put(i, i)
+	}
+	if n := presized.GrowCount(); n != 0 {
+		t.Errorf("a presized map grew %d times", n)
+	}
+	// from 1 to 256 buckets
+	if n := m.GrowCount(); n < 8 {
+		t.Errorf("expected at least 8 grows, got %d", n)
+	}
+	m.ResetGrowCount()
+	if n := m.GrowCount(); n != 0 {
+		t.Errorf("GrowCount is %d after ResetGrowCount", n)
+	}
+}
+
+func TestMapGetOrPut(t *testing.T) {
+	m, err := LoadMap(map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if v, loaded := m.GetOrPut("a", 1); loaded || v != 1 {
+		t.Errorf("first GetOrPut returned %v, %v", v, loaded)
+	}
+	if v, loaded := m.GetOrPut("a", 2); !loaded || v != 1 {
+		t.Errorf("second GetOrPut returned %v, %v", v, loaded)
+	}
+	if v, loaded := m.GetOrPut("nil", nil); loaded || v != nil {
+		t.Errorf("GetOrPut of nil returned %v, %v", v, loaded)
+	}
+	if v, loaded := m.GetOrPut("nil", 3); !loaded || v != nil {
+		t.Errorf("GetOrPut of a stored nil returned %v, %v", v, loaded)
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", m.Len())
+	}
+}
@@ -19,11 +19,15 @@ func init() {
 	strIMapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
 }
 
-func NewStrIMap() *StrIMap {
+func NewStrIMap(size ...int32) *StrIMap {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
 	typ := &*strIMapTyp
 	return &StrIMap{
 		typ: typ,
-		hm:  makemap(typ, 0, nil, nil),
+		hm:  makemap(typ, int64(sz), nil, nil),
 	}
 }
 
@@ -45,6 +49,11 @@ func (m *StrIMap) KeyType() string {
 	return m.typ.Key.String()
 }
 
+// Len returns the number of entries in the map.
+func (m *StrIMap) Len() int {
+	return m.hm.len()
+}
+
 func (m *StrIMap) GetPtr(key string) unsafe.Pointer {
 	return mapaccess1_faststr(m.typ, m.hm, key)
 }
@@ -53,7 +62,13 @@ func (m *StrIMap) GetPtrOk(key string) (unsafe.Pointer, bool) {
 	return mapaccess2_faststr(m.typ, m.hm, key)
 }
 
+// Has reports whether the key is present, even with a zero value
+func (m *StrIMap) Has(key string) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
 func (m *StrIMap) Put(key string, value interface{}) {
 	p := mapassign(m.typ, m.hm, unsafe.Pointer(&key))
-	runtimer.Typedmemmove(m.typ.Elem, p, *runtimer.EfaceDataPtr(&value))
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
 }
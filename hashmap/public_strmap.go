@@ -1,14 +1,23 @@
 package hashmap
 
 import (
+	"iter"
 	"unsafe" // #nosec
 
 	"github.com/gramework/runtimer"
 )
 
 type StrMap struct {
-	hm  *hmap
-	typ *runtimer.MapType
+	hm    *hmap
+	typ   *runtimer.MapType
+	bloom *bloomFilter
+	rec   *AccessRecorder
+	norm  func(string) string
+
+	keyBytes int // running total of len(key) over all entries
+
+	version  uint64
+	versions map[string]uint64 // version of the last Put of each key, see WithVersions
 }
 
 var strMapTyp *runtimer.MapType
@@ -19,14 +28,27 @@ func init() {
 	strMapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
 }
 
-func NewStrMap() *StrMap {
+func NewStrMap(size ...int32) *StrMap {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
 	typ := &*strMapTyp
 	return &StrMap{
 		typ: typ,
-		hm:  makemap(typ, 0, nil, nil),
+		hm:  makemap(typ, int64(sz), nil, nil),
 	}
 }
 
+// NewStrMapNormalized creates a StrMap that applies norm to every key
+// passed to Put, GetPtr, GetPtrOk and Delete, so keys with the same
+// normalized form collide. Iteration returns the normalized keys.
+func NewStrMapNormalized(norm func(string) string) *StrMap {
+	m := NewStrMap()
+	m.norm = norm
+	return m
+}
+
 func LoadStrMap(m map[string]string) (*StrMap, error) {
 	if m == nil {
 		return nil, ErrNoData
@@ -37,6 +59,7 @@ func LoadStrMap(m map[string]string) (*StrMap, error) {
 		typ: (*runtimer.MapType)(unsafe.Pointer(e.typ)),
 		hm:  (*hmap)(e.word),
 	}
+	loadedmap.keyBytes = loadedmap.scanKeyBytes()
 
 	return loadedmap, nil
 }
@@ -45,15 +68,233 @@ func (m *StrMap) KeyType() string {
 	return m.typ.Key.String()
 }
 
+// Len returns the number of entries in the map.
+func (m *StrMap) Len() int {
+	return m.hm.len()
+}
+
 func (m *StrMap) GetPtr(key string) unsafe.Pointer {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	if m.rec != nil {
+		m.rec.sample(key)
+	}
+	if m.bloom != nil && !m.bloom.mayContain(m.typ, key) {
+		return unsafe.Pointer(&zeroVal[0])
+	}
 	return mapaccess1_faststr(m.typ, m.hm, *runtimer.PtrToStringPtr(runtimer.GetEfaceDataPtr(&key)))
 }
 
 func (m *StrMap) GetPtrOk(key string) (unsafe.Pointer, bool) {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	if m.rec != nil {
+		m.rec.sample(key)
+	}
+	if m.bloom != nil && !m.bloom.mayContain(m.typ, key) {
+		return unsafe.Pointer(&zeroVal[0]), false
+	}
 	return mapaccess2_faststr(m.typ, m.hm, key)
 }
 
+// Has reports whether the key is present, even with a zero value
+func (m *StrMap) Has(key string) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
+// Get returns the value stored for the key. The returned string
+// stays valid after the entry is changed or deleted.
+func (m *StrMap) Get(key string) (string, bool) {
+	p, ok := m.GetPtrOk(key)
+	return *(*string)(p), ok
+}
+
+// GetOrDefault is like Get, but returns def if there's no value for the key.
+func (m *StrMap) GetOrDefault(key, def string) string {
+	if v, ok := m.Get(key); ok {
+		return v
+	}
+	return def
+}
+
 func (m *StrMap) Put(key, value string) {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	m.put(key, value)
+}
+
+// Insert stores the value only if the key is not present yet,
+// and returns ErrKeyExists otherwise.
+func (m *StrMap) Insert(key, value string) error {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	if _, ok := mapaccess2_faststr(m.typ, m.hm, key); ok {
+		return ErrKeyExists
+	}
+	m.put(key, value)
+	return nil
+}
+
+// Upsert stores f(old, existed), where old is the current value for the key,
+// or "" if there's none. Like the rest of StrMap it's not safe for concurrent use.
+func (m *StrMap) Upsert(key string, f func(old string, existed bool) string) {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	p, ok := mapaccess2_faststr(m.typ, m.hm, key)
+	m.put(key, f(*(*string)(p), ok))
+}
+
+// PutBounded stores the value only if len(key) is at most maxKeyLen
+// and len(value) is at most maxValLen, and returns ErrKeyTooLong
+// or ErrValueTooLong otherwise.
+func (m *StrMap) PutBounded(key, value string, maxKeyLen, maxValLen int) error {
+	if len(key) > maxKeyLen {
+		return ErrKeyTooLong
+	}
+	if len(value) > maxValLen {
+		return ErrValueTooLong
+	}
+	m.Put(key, value)
+	return nil
+}
+
+// put expects the key to be normalized already
+func (m *StrMap) put(key, value string) {
+	n := m.hm.count
 	p := mapassign_faststr(m.typ, m.hm, key)
+	if m.hm.count != n {
+		m.keyBytes += len(key)
+	}
 	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
+	m.version++
+	if m.versions != nil {
+		m.versions[key] = m.version
+	}
+	if m.bloom != nil {
+		if m.hm.count > m.bloom.keys {
+			m.rebuildBloom()
+		} else {
+			m.bloom.add(m.typ, key)
+		}
+	}
+}
+
+// Delete a key. Since a Bloom filter can't forget keys,
+// it also drops the filter enabled by WithBloom.
+func (m *StrMap) Delete(key string) {
+	if m.norm != nil {
+		key = m.norm(key)
+	}
+	n := m.hm.len()
+	mapdelete_faststr(m.typ, m.hm, key)
+	if m.hm.len() != n {
+		m.keyBytes -= len(key)
+	}
+	delete(m.versions, key)
+	m.bloom = nil
+}
+
+// TotalKeyBytes returns the sum of len(key) over all entries.
+// It is kept up to date by Put and Delete, so it's O(1).
+func (m *StrMap) TotalKeyBytes() int {
+	return m.keyBytes
+}
+
+func (m *StrMap) scanKeyBytes() int {
+	total := 0
+	mapiterate(m.typ, m.hm, func(k, _ unsafe.Pointer) bool {
+		total += len(*(*string)(k))
+		return true
+	})
+	return total
+}
+
+// Version returns the map version, which is bumped by every Put
+func (m *StrMap) Version() uint64 {
+	return m.version
+}
+
+// WithVersions records the version of each Put for ChangesSince. It keeps
+// a copy of every key put from then on, so it's off by default.
+func (m *StrMap) WithVersions() *StrMap {
+	if m.versions == nil {
+		m.versions = make(map[string]uint64)
+	}
+	return m
+}
+
+// ChangesSince returns the entries put after the map was at version v, or
+// ErrNoVersions without WithVersions. Deleted keys are not reported, and
+// entries that weren't Put through this StrMap since WithVersions (e.g.
+// those loaded by LoadStrMap) are at version 0.
+func (m *StrMap) ChangesSince(v uint64) (map[string]string, error) {
+	if m.versions == nil {
+		return nil, ErrNoVersions
+	}
+	changes := make(map[string]string)
+	for key, ver := range m.versions {
+		if ver <= v {
+			continue
+		}
+		if vp, ok := mapaccess2_faststr(m.typ, m.hm, key); ok {
+			changes[key] = *(*string)(vp)
+		}
+	}
+	return changes, nil
+}
+
+// WithBloom puts a Bloom filter in front of the lookups, so most lookups
+// of absent keys are answered without probing the buckets.
+// The filter is dropped by Delete; call WithBloom again to rebuild it.
+// Writes to the native map passed to LoadStrMap bypass the filter,
+// so don't mix them with WithBloom.
+func (m *StrMap) WithBloom() *StrMap {
+	m.rebuildBloom()
+	return m
+}
+
+func (m *StrMap) rebuildBloom() {
+	f := newBloomFilter(2 * m.hm.len())
+	mapiterate(m.typ, m.hm, func(k, _ unsafe.Pointer) bool {
+		f.add(m.typ, *(*string)(k))
+		return true
+	})
+	m.bloom = f
+}
+
+// Entry is a key/value pair of a StrMap
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// Entries returns a snapshot of all entries in iteration order
+func (m *StrMap) Entries() []Entry {
+	return m.AppendEntries(make([]Entry, 0, m.hm.len()))
+}
+
+// AppendEntries appends all entries to dst and returns the extended slice.
+// Pass a reused buffer as dst[:0] to snapshot the map without allocating.
+func (m *StrMap) AppendEntries(dst []Entry) []Entry {
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		dst = append(dst, Entry{Key: *(*string)(k), Value: *(*string)(v)})
+		return true
+	})
+	return dst
+}
+
+// All returns an iterator over the entries, for use with range.
+// Changes made during the loop behave as with a built-in map.
+func (m *StrMap) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+			return yield(*(*string)(k), *(*string)(v))
+		})
+	}
 }
@@ -0,0 +1,407 @@
+package hashmap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"unsafe" // #nosec
+)
+
+func TestStrMapAppendEntries(t *testing.T) {
+	m := NewStrMap()
+	for i := 0; i < 100; i++ {
+		m.Put(fmt.Sprint(i), fmt.Sprint("v", i))
+	}
+	head := []Entry{{Key: "head", Value: "head"}}
+	res := m.AppendEntries(head)
+	if len(res) != 101 {
+		t.Fatalf("expected 101 entries, got %d", len(res))
+	}
+	if res[0] != head[0] {
+		t.Errorf("existing dst element was overwritten: %+v", res[0])
+	}
+	seen := make(map[string]bool)
+	for _, e := range res[1:] {
+		if seen[e.Key] {
+			t.Errorf("key %q appended twice", e.Key)
+		}
+		seen[e.Key] = true
+		if e.Value != "v"+e.Key {
+			t.Errorf("unexpected value for %q: %q", e.Key, e.Value)
+		}
+	}
+	if len(m.Entries()) != 100 {
+		t.Errorf("expected 100 entries in a fresh snapshot")
+	}
+}
+
+func BenchmarkStrMapEntries_1024(b *testing.B)       { benchmarkStrMapEntries(b, 1024, false) }
+func BenchmarkStrMapAppendEntries_1024(b *testing.B) { benchmarkStrMapEntries(b, 1024, true) }
+
+func benchmarkStrMapEntries(b *testing.B, keys int, reuse bool) {
+	m := NewStrMap()
+	for i := 0; i < keys; i++ {
+		m.Put(fmt.Sprint(i), "")
+	}
+	var buf []Entry
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if reuse {
+			buf = m.AppendEntries(buf[:0])
+		} else {
+			buf = m.Entries()
+		}
+	}
+}
+
+func TestStrMapIterateEmpty(t *testing.T) {
+	for name, m := range map[string]*StrMap{
+		"zero value": {},
+		"empty":      NewStrMap(),
+	} {
+		if e := m.Entries(); len(e) != 0 {
+			t.Errorf("%s: got %d entries", name, len(e))
+		}
+		dst := []Entry{{Key: "k"}}
+		if e := m.AppendEntries(dst); len(e) != 1 {
+			t.Errorf("%s: AppendEntries changed dst to %+v", name, e)
+		}
+		mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+			t.Errorf("%s: callback invoked", name)
+			return true
+		})
+	}
+}
+
+func TestStrMapAll(t *testing.T) {
+	m := NewStrMap()
+	for i := 0; i < 100; i++ {
+		m.Put(fmt.Sprint(i), fmt.Sprint("v", i))
+	}
+	got := map[string]string{}
+	for k, v := range m.All() {
+		got[k] = v
+	}
+	if len(got) != 100 {
+		t.Errorf("expected 100 entries, got %d", len(got))
+	}
+	for k, v := range got {
+		if v != "v"+k {
+			t.Errorf("unexpected value for %q: %q", k, v)
+		}
+	}
+
+	n := 0
+	for range m.All() {
+		if n++; n == 3 {
+			break
+		}
+	}
+	if n != 3 {
+		t.Errorf("break didn't stop the loop, %d iterations", n)
+	}
+	for range (&StrMap{}).All() {
+		t.Errorf("zero StrMap yielded an entry")
+	}
+}
+
+func TestStrMapAccessRecorder(t *testing.T) {
+	m := NewStrMap()
+	m.Put("hot", "")
+	sampled := 0
+	m.SetAccessRecorder(&AccessRecorder{
+		N: 100,
+		Record: func(key string) {
+			if key != "hot" {
+				t.Errorf("unexpected sampled key %q", key)
+			}
+			sampled++
+		},
+	})
+	for i := 0; i < 100000; i++ {
+		_ = m.GetPtr("hot")
+	}
+	// expect about 1000 samples
+	if sampled < 800 || sampled > 1200 {
+		t.Errorf("expected about 1000 sampled lookups, got %d", sampled)
+	}
+
+	m.SetAccessRecorder(nil)
+	sampled = 0
+	for i := 0; i < 1000; i++ {
+		_, _ = m.GetPtrOk("hot")
+	}
+	if sampled != 0 {
+		t.Errorf("lookups sampled after disabling the recorder")
+	}
+}
+
+func TestStrMapNormalized(t *testing.T) {
+	m := NewStrMapNormalized(strings.TrimSpace)
+	m.Put(" a ", "1")
+	m.Put("a", "2")
+	if vp, ok := m.GetPtrOk("  a"); !ok || *(*string)(vp) != "2" {
+		t.Errorf(`expected " a " and "a" to collide`)
+	}
+	e := m.Entries()
+	if len(e) != 1 || e[0].Key != "a" {
+		t.Errorf("expected a single normalized key, got %+v", e)
+	}
+	m.Delete("a ")
+	if _, ok := m.GetPtrOk("a"); ok {
+		t.Errorf("Delete didn't normalize the key")
+	}
+}
+
+func TestStrMapChangesSince(t *testing.T) {
+	m := NewStrMap()
+	m.Put("x", "1")
+	if changes, err := m.ChangesSince(0); err != ErrNoVersions || changes != nil {
+		t.Errorf("expected ErrNoVersions without WithVersions, got %v, %v", changes, err)
+	}
+	m.Delete("x")
+	m.WithVersions()
+	m.Put("a", "1")
+	m.Put("b", "1")
+	v := m.Version()
+	if v != 3 {
+		t.Errorf("expected version 3 after three Puts, got %d", v)
+	}
+	m.Put("b", "2")
+	m.Put("c", "1")
+	m.Put("d", "1")
+	m.Delete("d")
+
+	changes, err := m.ChangesSince(v)
+	if err != nil {
+		t.Fatalf("ChangesSince failed: %s", err)
+	}
+	if len(changes) != 2 || changes["b"] != "2" || changes["c"] != "1" {
+		t.Errorf("unexpected changes since %d: %v", v, changes)
+	}
+	if changes, _ := m.ChangesSince(m.Version()); len(changes) != 0 {
+		t.Errorf("unexpected changes since the current version: %v", changes)
+	}
+	if changes, _ := m.ChangesSince(0); len(changes) != 3 {
+		t.Errorf("expected all 3 entries since version 0, got %v", changes)
+	}
+}
+
+func TestStrMapInsert(t *testing.T) {
+	m := NewStrMap()
+	if err := m.Insert("a", "1"); err != nil {
+		t.Errorf("first insert failed: %s", err)
+	}
+	if err := m.Insert("a", "2"); err != ErrKeyExists {
+		t.Errorf("expected ErrKeyExists on duplicate insert, got %v", err)
+	}
+	if v := *(*string)(m.GetPtr("a")); v != "1" {
+		t.Errorf("duplicate insert changed the value to %q", v)
+	}
+}
+
+func TestStrMapGetOrDefault(t *testing.T) {
+	m := NewStrMap()
+	m.Put("a", "1")
+	m.Put("empty", "")
+	for key, want := range map[string]string{
+		"a":       "1",
+		"empty":   "",
+		"missing": "def",
+	} {
+		if got := m.GetOrDefault(key, "def"); got != want {
+			t.Errorf("GetOrDefault(%q) = %q, expected %q", key, got, want)
+		}
+	}
+}
+
+func TestStrMapGet(t *testing.T) {
+	m := NewStrMap()
+	m.Put("k", "v")
+	m.Put("empty", "")
+	v, ok := m.Get("k")
+	m.Put("k", "changed")
+	m.Delete("k")
+	if !ok || v != "v" {
+		t.Errorf("Get returned %q, %v", v, ok)
+	}
+	if v, ok := m.Get("empty"); !ok || v != "" {
+		t.Errorf("Get of an empty value returned %q, %v", v, ok)
+	}
+	if v, ok := m.Get("k"); ok || v != "" {
+		t.Errorf("Get of a deleted key returned %q, %v", v, ok)
+	}
+	if v := m.GetOrDefault("k", "def"); v != "def" {
+		t.Errorf("GetOrDefault returned %q", v)
+	}
+	if v := m.GetOrDefault("empty", "def"); v != "" {
+		t.Errorf("GetOrDefault of an empty value returned %q", v)
+	}
+}
+
+func TestStrMapUpsert(t *testing.T) {
+	m := NewStrMap()
+	appendX := func(old string, existed bool) string {
+		if !existed && old != "" {
+			t.Errorf("absent key passed old value %q", old)
+		}
+		return old + "x"
+	}
+	m.Upsert("k", appendX)
+	m.Upsert("k", appendX)
+	if v := m.GetOrDefault("k", "none"); v != "xx" {
+		t.Errorf("expected %q, got %q", "xx", v)
+	}
+	m.Upsert("other", func(old string, existed bool) string {
+		if existed {
+			t.Errorf("new key reported as existing")
+		}
+		return "init"
+	})
+	if v := m.GetOrDefault("other", "none"); v != "init" {
+		t.Errorf("expected %q, got %q", "init", v)
+	}
+}
+
+func TestStrMapTotalKeyBytes(t *testing.T) {
+	m := NewStrMap()
+	for i := 0; i < 1000; i++ {
+		m.Put(strings.Repeat("k", i%17)+fmt.Sprint(i), "")
+	}
+	for i := 0; i < 1000; i += 3 {
+		m.Delete(strings.Repeat("k", i%17) + fmt.Sprint(i))
+	}
+	m.Put("1", "overwritten")
+	m.Delete("missing")
+	if total, scanned := m.TotalKeyBytes(), m.scanKeyBytes(); total != scanned {
+		t.Errorf("running total is %d, full scan gives %d", total, scanned)
+	}
+
+	l, err := LoadStrMap(map[string]string{"abc": "", "de": ""})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if total := l.TotalKeyBytes(); total != 5 {
+		t.Errorf("expected 5 key bytes in a loaded map, got %d", total)
+	}
+}
+
+func TestStrMapScanPage(t *testing.T) {
+	m := NewStrMap()
+	const keys = 10000
+	for i := 0; i < keys; i++ {
+		m.Put(fmt.Sprint(i), "")
+	}
+	seen := make(map[string]bool)
+	pages := 0
+	cursor := uint64(0)
+	for {
+		page, next := m.ScanPage(cursor, 100)
+		pages++
+		for _, key := range page {
+			if seen[key] {
+				t.Errorf("key %q returned twice", key)
+			}
+			seen[key] = true
+		}
+		if next == 0 {
+			break
+		}
+		if next <= cursor {
+			t.Fatalf("cursor went from %d to %d", cursor, next)
+		}
+		cursor = next
+	}
+	if len(seen) != keys {
+		t.Errorf("expected %d keys, scanned %d", keys, len(seen))
+	}
+	if pages < keys/200 {
+		t.Errorf("expected the scan to be paged, got %d pages", pages)
+	}
+
+	if page, next := NewStrMap().ScanPage(0, 10); len(page) != 0 || next != 0 {
+		t.Errorf("unexpected page of an empty map: %v, %d", page, next)
+	}
+}
+
+func TestStrMapKeysMatching(t *testing.T) {
+	m := NewStrMap()
+	for _, key := range []string{"user:1", "user:2", "admin:user:3", "group:1"} {
+		m.Put(key, "")
+	}
+	for pattern, expected := range map[string][]string{
+		"^user:":  {"user:1", "user:2"},
+		"user":    {"admin:user:3", "user:1", "user:2"},
+		":1$":     {"group:1", "user:1"},
+		"^none$":  {},
+		"[0-9]+$": {"admin:user:3", "group:1", "user:1", "user:2"},
+	} {
+		keys, err := m.KeysMatching(pattern)
+		if err != nil {
+			t.Errorf("%q: %s", pattern, err)
+			continue
+		}
+		sort.Strings(keys)
+		if strings.Join(keys, " ") != strings.Join(expected, " ") {
+			t.Errorf("%q: expected %v, got %v", pattern, expected, keys)
+		}
+	}
+	if _, err := m.KeysMatching("user:("); err == nil {
+		t.Errorf("expected an error for an invalid pattern")
+	}
+}
+
+func TestStrMapPutBounded(t *testing.T) {
+	m := NewStrMap()
+	if err := m.PutBounded("key", "value", 3, 5); err != nil {
+		t.Errorf("within limits: %s", err)
+	}
+	if err := m.PutBounded("long key", "v", 3, 5); err != ErrKeyTooLong {
+		t.Errorf("expected ErrKeyTooLong, got %v", err)
+	}
+	if err := m.PutBounded("k", "long value", 3, 5); err != ErrValueTooLong {
+		t.Errorf("expected ErrValueTooLong, got %v", err)
+	}
+	if _, ok := m.GetPtrOk("long key"); ok {
+		t.Errorf("an oversized key was stored")
+	}
+	if _, ok := m.GetPtrOk("k"); ok {
+		t.Errorf("an oversized value was stored")
+	}
+	if v := *(*string)(m.GetPtr("key")); v != "value" {
+		t.Errorf("expected value, got %q", v)
+	}
+}
+
+func TestStrMapSubmapByPrefix(t *testing.T) {
+	m := NewStrMap()
+	for _, key := range []string{"db.host", "db.port", "db.replica.host", "http.port", "db"} {
+		m.Put(key, key)
+	}
+	entries := func(m *StrMap) string {
+		var res []string
+		for _, e := range m.Entries() {
+			res = append(res, e.Key+"="+e.Value)
+		}
+		sort.Strings(res)
+		return strings.Join(res, " ")
+	}
+
+	for _, c := range []struct {
+		prefix   string
+		strip    bool
+		expected string
+	}{
+		{"db.", false, "db.host=db.host db.port=db.port db.replica.host=db.replica.host"},
+		{"db.", true, "host=db.host port=db.port replica.host=db.replica.host"},
+		{"db.replica.", true, "host=db.replica.host"},
+		{"db.replica.", false, "db.replica.host=db.replica.host"},
+		{"none.", true, ""},
+	} {
+		if res := entries(m.SubmapByPrefix(c.prefix, c.strip)); res != c.expected {
+			t.Errorf("%q, strip %v: expected %q, got %q", c.prefix, c.strip, c.expected, res)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// StrSet is a set of strings, a map[string]struct{}.
+// Its values take no space, so only the keys are ever copied.
+type StrSet struct {
+	hm  *hmap
+	typ *runtimer.MapType
+}
+
+var strSetTyp *runtimer.MapType
+
+func init() {
+	mi := interface{}(map[string]struct{}{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	strSetTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
+}
+
+func NewStrSet(size ...int32) *StrSet {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
+	typ := &*strSetTyp
+	return &StrSet{
+		typ: typ,
+		hm:  makemap(typ, int64(sz), nil, nil),
+	}
+}
+
+// Len returns the number of strings in the set.
+func (s *StrSet) Len() int {
+	return s.hm.len()
+}
+
+func (s *StrSet) Add(key string) {
+	mapassign_faststr(s.typ, s.hm, key)
+}
+
+func (s *StrSet) Remove(key string) {
+	mapdelete_faststr(s.typ, s.hm, key)
+}
+
+func (s *StrSet) Contains(key string) bool {
+	_, ok := mapaccess2_faststr(s.typ, s.hm, key)
+	return ok
+}
+
+// Range calls fn for each string until fn returns false.
+func (s *StrSet) Range(fn func(key string) bool) {
+	mapiterate(s.typ, s.hm, func(k, _ unsafe.Pointer) bool {
+		return fn(*(*string)(k))
+	})
+}
+
+// Union returns a new set of the strings in s or other.
+func (s *StrSet) Union(other *StrSet) *StrSet {
+	u := NewStrSet(int32(s.Len() + other.Len()))
+	s.Range(func(key string) bool {
+		u.Add(key)
+		return true
+	})
+	other.Range(func(key string) bool {
+		u.Add(key)
+		return true
+	})
+	return u
+}
+
+// Intersect returns a new set of the strings in both s and other.
+func (s *StrSet) Intersect(other *StrSet) *StrSet {
+	small, big := s, other
+	if small.Len() > big.Len() {
+		small, big = big, small
+	}
+	i := NewStrSet()
+	small.Range(func(key string) bool {
+		if big.Contains(key) {
+			i.Add(key)
+		}
+		return true
+	})
+	return i
+}
+
+// Difference returns a new set of the strings in s but not in other.
+func (s *StrSet) Difference(other *StrSet) *StrSet {
+	d := NewStrSet()
+	s.Range(func(key string) bool {
+		if !other.Contains(key) {
+			d.Add(key)
+		}
+		return true
+	})
+	return d
+}
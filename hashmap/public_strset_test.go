@@ -0,0 +1,62 @@
+package hashmap
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func strSetOf(keys ...string) *StrSet {
+	s := NewStrSet()
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+func sortedKeys(s *StrSet) string {
+	var keys []string
+	s.Range(func(key string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+func TestStrSet(t *testing.T) {
+	s := strSetOf("a", "b", "", "a")
+	if s.Len() != 3 {
+		t.Errorf("expected 3 strings, got %d", s.Len())
+	}
+	if !s.Contains("") || !s.Contains("b") || s.Contains("c") {
+		t.Errorf("Contains is wrong")
+	}
+	s.Remove("b")
+	s.Remove("c")
+	if got := sortedKeys(s); got != ",a" {
+		t.Errorf("unexpected strings %q", got)
+	}
+}
+
+func TestStrSetOps(t *testing.T) {
+	a := strSetOf("a", "b", "c")
+	b := strSetOf("b", "c", "d")
+	for _, tc := range []struct {
+		name string
+		set  *StrSet
+		want string
+	}{
+		{"Union", a.Union(b), "a,b,c,d"},
+		{"Intersect", a.Intersect(b), "b,c"},
+		{"Difference", a.Difference(b), "a"},
+		{"Difference of the same set", a.Difference(a), ""},
+	} {
+		if got := sortedKeys(tc.set); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := sortedKeys(a); got != "a,b,c" {
+		t.Errorf("the operations changed a to %q", got)
+	}
+}
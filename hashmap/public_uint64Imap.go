@@ -0,0 +1,90 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// Uint64IMap is a map[uint64]interface{} for hashes and IDs. It uses the
+// fast64 map functions, which compare the raw bits of the keys, so keys
+// of 1<<63 and above are distinct keys like any other, with no sign to lose.
+type Uint64IMap struct {
+	hm  *hmap
+	typ *runtimer.MapType
+}
+
+var uint64IMapTyp *runtimer.MapType
+
+func init() {
+	mi := interface{}(map[uint64]interface{}{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	uint64IMapTyp = (*runtimer.MapType)(unsafe.Pointer(e.typ))
+}
+
+func NewUint64IMap(size ...int32) *Uint64IMap {
+	sz := int32(0)
+	if len(size) > 0 {
+		sz = size[0]
+	}
+	typ := &*uint64IMapTyp
+	return &Uint64IMap{
+		typ: typ,
+		hm:  makemap(typ, int64(sz), nil, nil),
+	}
+}
+
+func LoadUint64IMap(m map[uint64]interface{}) (*Uint64IMap, error) {
+	if m == nil {
+		return nil, ErrNoData
+	}
+	mi := interface{}(m)
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	loadedmap := &Uint64IMap{
+		typ: (*runtimer.MapType)(unsafe.Pointer(e.typ)),
+		hm:  (*hmap)(e.word),
+	}
+
+	return loadedmap, nil
+}
+
+func (m *Uint64IMap) KeyType() string {
+	return m.typ.Key.String()
+}
+
+// Len returns the number of entries in the map.
+func (m *Uint64IMap) Len() int {
+	return m.hm.len()
+}
+
+func (m *Uint64IMap) GetPtr(key uint64) unsafe.Pointer {
+	return mapaccess1_fast64(m.typ, m.hm, key)
+}
+
+func (m *Uint64IMap) GetPtrOk(key uint64) (unsafe.Pointer, bool) {
+	return mapaccess2_fast64(m.typ, m.hm, key)
+}
+
+// Has reports whether the key is present, even with a zero value
+func (m *Uint64IMap) Has(key uint64) bool {
+	_, ok := m.GetPtrOk(key)
+	return ok
+}
+
+func (m *Uint64IMap) Put(key uint64, value interface{}) {
+	p := mapassign_fast64(m.typ, m.hm, key)
+	runtimer.Typedmemmove(m.typ.Elem, p, unsafe.Pointer(&value))
+}
+
+// Get returns the value stored for the key, or nil, false
+func (m *Uint64IMap) Get(key uint64) (interface{}, bool) {
+	p, ok := mapaccess2_fast64(m.typ, m.hm, key)
+	if !ok {
+		return nil, false
+	}
+	return *(*interface{})(p), true
+}
+
+func (m *Uint64IMap) Delete(key uint64) {
+	mapdelete_fast64(m.typ, m.hm, key)
+}
@@ -0,0 +1,40 @@
+package hashmap
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUint64IMap(t *testing.T) {
+	m := NewUint64IMap()
+	keys := []uint64{0, 1, 1 << 63, math.MaxUint64, math.MaxUint64 - 1, 1 << 40}
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	if n := m.Len(); n != len(keys) {
+		t.Fatalf("expected %d entries, got %d", len(keys), n)
+	}
+	for i, k := range keys {
+		if v, ok := m.Get(k); !ok || v != i {
+			t.Errorf("key %d: got %v, %v", k, v, ok)
+		}
+	}
+	if _, ok := m.Get(2); ok {
+		t.Errorf("missing key was found")
+	}
+	m.Delete(math.MaxUint64)
+	if m.Has(math.MaxUint64) || !m.Has(math.MaxUint64-1) {
+		t.Errorf("Delete removed the wrong key")
+	}
+
+	l, err := LoadUint64IMap(map[uint64]interface{}{1 << 63: "big"})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	if v, _ := l.Get(1 << 63); v != "big" {
+		t.Errorf("unexpected value in a loaded map: %v", v)
+	}
+	if _, err := LoadUint64IMap(nil); err != ErrNoData {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package hashmap
+
+import (
+	"regexp"
+	"strings"
+	"unsafe" // #nosec
+)
+
+// KeysMatching returns the keys matching the regular expression pattern.
+// It scans the whole map, so it's O(n).
+func (m *StrMap) KeysMatching(pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	mapiterate(m.typ, m.hm, func(k, _ unsafe.Pointer) bool {
+		if key := *(*string)(k); re.MatchString(key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	return keys, nil
+}
+
+// SubmapByPrefix returns a new StrMap with the entries whose keys start
+// with prefix. If strip is true, the prefix is removed from the keys.
+func (m *StrMap) SubmapByPrefix(prefix string, strip bool) *StrMap {
+	sub := NewStrMap()
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		key := *(*string)(k)
+		if !strings.HasPrefix(key, prefix) {
+			return true
+		}
+		if strip {
+			key = key[len(prefix):]
+		}
+		sub.Put(key, *(*string)(v))
+		return true
+	})
+	return sub
+}
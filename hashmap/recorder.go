@@ -0,0 +1,23 @@
+package hashmap
+
+import "github.com/gramework/runtimer"
+
+// AccessRecorder samples the lookups of a map for profiling:
+// on average, Record is called with the key of one in N lookups.
+type AccessRecorder struct {
+	N      uint32
+	Record func(key string)
+}
+
+func (r *AccessRecorder) sample(key string) {
+	if r.N > 1 && runtimer.Fastrand()%r.N != 0 {
+		return
+	}
+	r.Record(key)
+}
+
+// SetAccessRecorder enables sampling of GetPtr and GetPtrOk calls.
+// Pass nil to disable it.
+func (m *StrMap) SetAccessRecorder(r *AccessRecorder) {
+	m.rec = r
+}
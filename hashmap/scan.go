@@ -0,0 +1,29 @@
+package hashmap
+
+import "unsafe" // #nosec
+
+// ScanPage returns the keys of a page of buckets, starting at the bucket
+// given by cursor, and the cursor of the next page. Start with cursor 0;
+// a nextCursor of 0 means the scan is complete. The cursor is just a bucket
+// index, so no state is kept between calls. Whole buckets are returned,
+// so a page may have a few more keys than limit.
+// Like Redis SCAN, keys may be missed or returned twice if the map is
+// modified between calls.
+func (m *StrMap) ScanPage(cursor uint64, limit int) (keys []string, nextCursor uint64) {
+	h := m.hm
+	if h.len() == 0 {
+		return nil, 0
+	}
+	nbuckets := uint64(1) << h.B
+	for bucket := cursor; bucket < nbuckets; {
+		bucketEntries(m.typ, h, uintptr(bucket), func(k, _ unsafe.Pointer) bool {
+			keys = append(keys, *(*string)(k))
+			return true
+		})
+		bucket++
+		if len(keys) >= limit && bucket < nbuckets {
+			return keys, bucket
+		}
+	}
+	return keys, 0
+}
@@ -0,0 +1,74 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// DefaultShards is the number of shards used by NewShardedMap
+// when it's passed a non-positive count.
+const DefaultShards = 32
+
+// ShardedMap spreads the keys over independently locked ConcurrentMaps,
+// so writers of different shards don't wait for each other.
+type ShardedMap struct {
+	shards []*ConcurrentMap
+	hash   func(unsafe.Pointer, uintptr) uintptr
+	seed   uintptr
+}
+
+// NewShardedMap creates an empty ShardedMap with the type of the map
+// passed as typ, e.g. map[string]int{}, and the given number of shards.
+// The contents of typ aren't used.
+func NewShardedMap(typ interface{}, shards int) (*ShardedMap, error) {
+	tm, err := LoadMap(typ)
+	if err != nil {
+		return nil, err
+	}
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	m := &ShardedMap{
+		shards: make([]*ConcurrentMap, shards),
+		hash:   tm.typ.Key.Alg.Hash,
+		seed:   uintptr(runtimer.Fastrand()),
+	}
+	for i := range m.shards {
+		m.shards[i] = NewConcurrentMap(&Map{
+			typ:   tm.typ,
+			ikey:  tm.ikey,
+			ielem: tm.ielem,
+			hm:    makemap(tm.typ, 0, nil, nil),
+		})
+	}
+	return m, nil
+}
+
+// shard picks the shard of the key with the key type's own hash function
+func (m *ShardedMap) shard(key interface{}) *ConcurrentMap {
+	h := m.hash(m.shards[0].m.keyPtr(key), m.seed)
+	return m.shards[h%uintptr(len(m.shards))]
+}
+
+func (m *ShardedMap) Get(key interface{}) (interface{}, bool) {
+	return m.shard(key).Get(key)
+}
+
+func (m *ShardedMap) Put(key, value interface{}) {
+	m.shard(key).Put(key, value)
+}
+
+func (m *ShardedMap) Delete(key interface{}) {
+	m.shard(key).Delete(key)
+}
+
+// Len returns the sum of the shard lengths. The shards are
+// counted one by one, so it's not a consistent snapshot.
+func (m *ShardedMap) Len() int {
+	n := 0
+	for _, s := range m.shards {
+		n += s.Len()
+	}
+	return n
+}
@@ -0,0 +1,62 @@
+package hashmap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedMap(t *testing.T) {
+	if _, err := NewShardedMap(nil, 0); err != ErrNoType {
+		t.Errorf("expected ErrNoType, got %v", err)
+	}
+	m, err := NewShardedMap(map[string]int{}, 0)
+	if err != nil {
+		t.Fatalf("Can't create map: %s", err)
+	}
+	if len(m.shards) != DefaultShards {
+		t.Errorf("expected %d shards, got %d", DefaultShards, len(m.shards))
+	}
+	const writers, keys = 8, 1000
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				m.Put(fmt.Sprint(w, "-", i), i)
+			}
+			m.Delete(fmt.Sprint(w, "-", 0))
+		}(w)
+	}
+	wg.Wait()
+	if n := m.Len(); n != writers*(keys-1) {
+		t.Errorf("expected %d entries, got %d", writers*(keys-1), n)
+	}
+	for _, s := range m.shards {
+		// 7992 keys over 32 shards, 250 on average
+		if n := s.Len(); n < 125 || n > 500 {
+			t.Errorf("uneven sharding: a shard has %d keys", n)
+		}
+	}
+	if v, ok := m.Get("3-42"); !ok || v.(int) != 42 {
+		t.Errorf("Get returned %v, %v", v, ok)
+	}
+	if _, ok := m.Get("3-0"); ok {
+		t.Errorf("deleted key was found")
+	}
+}
+
+func BenchmarkShardedMapPut(b *testing.B) {
+	m, err := NewShardedMap(map[int]int{}, 0)
+	if err != nil {
+		b.Fatalf("Can't create map: %s", err)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Put(i&65535, i)
+			i++
+		}
+	})
+}
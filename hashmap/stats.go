@@ -0,0 +1,77 @@
+package hashmap
+
+import "unsafe" // #nosec
+
+// EstimatedBytes estimates the memory used by the map itself: its header,
+// buckets, overflow buckets, the old buckets of a grow in progress and the
+// keys and values too big to be stored in the buckets. Memory referenced by
+// the keys and values, such as string data, isn't counted.
+func (m *Map) EstimatedBytes() int {
+	h := m.hm
+	if h == nil {
+		return 0
+	}
+	bucketSize := int(m.typ.Bucketsize)
+	n := int(unsafe.Sizeof(*h))
+	if h.buckets != nil {
+		n += (1 << h.B) * bucketSize
+	}
+	if h.oldbuckets != nil {
+		n += int(h.noldbuckets()) * bucketSize
+	}
+	n += h.overflowBuckets() * bucketSize
+	if m.typ.Indirectkey {
+		n += h.count * int(m.typ.Key.Size)
+	}
+	if m.typ.Indirectvalue {
+		n += h.count * int(m.typ.Elem.Size)
+	}
+	return n
+}
+
+// BytesPerEntry returns EstimatedBytes amortized over the entries,
+// to project the memory needed for a number of entries.
+func (m *Map) BytesPerEntry() float64 {
+	n := m.Len()
+	if n < 1 {
+		n = 1
+	}
+	return float64(m.EstimatedBytes()) / float64(n)
+}
+
+// MapStats describes the internal state of a Map, see Map.Stats
+type MapStats struct {
+	Count   int
+	B       uint8 // log2 of Buckets
+	Buckets int
+	// NoverflowApprox is the overflow bucket counter that triggers
+	// same-size grows. On maps with B >= 16 it's only incremented
+	// for some of the overflow buckets.
+	NoverflowApprox int
+	// Growing is set while old buckets are still being evacuated.
+	Growing bool
+}
+
+// Stats returns the internal state of the map, for tuning.
+func (m *Map) Stats() MapStats {
+	h := m.hm
+	if h == nil {
+		return MapStats{}
+	}
+	return MapStats{
+		Count:           h.count,
+		B:               h.B,
+		Buckets:         1 << h.B,
+		NoverflowApprox: int(h.noverflow),
+		Growing:         h.oldbuckets != nil,
+	}
+}
+
+// overflowBuckets estimates the number of overflow buckets from noverflow,
+// which is only incremented for some of them on big maps, see incrnoverflow
+func (h *hmap) overflowBuckets() int {
+	if h.B < 16 {
+		return int(h.noverflow)
+	}
+	return int(h.noverflow) << (h.B - 15)
+}
@@ -0,0 +1,67 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// StructMap is a typed map for small comparable keys, such as
+// a struct{X, Y int} coordinate, that doesn't box keys or values.
+// It falls back to a built-in map if the runtime map type
+// of map[K]V can't be used.
+type StructMap[K comparable, V any] struct {
+	hm       *hmap
+	typ      *runtimer.MapType
+	fallback map[K]V
+}
+
+func NewStructMap[K comparable, V any]() *StructMap[K, V] {
+	mi := interface{}(map[K]V{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	typ := (*runtimer.MapType)(unsafe.Pointer(e.typ))
+	if typ.Key.Alg == nil || typ.Key.Alg.Hash == nil {
+		return &StructMap[K, V]{fallback: make(map[K]V)}
+	}
+	return &StructMap[K, V]{
+		typ: typ,
+		hm:  makemap(typ, 0, nil, nil),
+	}
+}
+
+func (m *StructMap[K, V]) Get(key K) (V, bool) {
+	if m.fallback != nil {
+		v, ok := m.fallback[key]
+		return v, ok
+	}
+	p, ok := mapaccess2(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return *(*V)(p), true
+}
+
+func (m *StructMap[K, V]) Put(key K, value V) {
+	if m.fallback != nil {
+		m.fallback[key] = value
+		return
+	}
+	p := mapassign(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+	*(*V)(p) = value
+}
+
+func (m *StructMap[K, V]) Delete(key K) {
+	if m.fallback != nil {
+		delete(m.fallback, key)
+		return
+	}
+	mapdelete(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+}
+
+func (m *StructMap[K, V]) Len() int {
+	if m.fallback != nil {
+		return len(m.fallback)
+	}
+	return m.hm.len()
+}
@@ -0,0 +1,39 @@
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStructMap(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	m := NewStructMap[point, string]()
+	if m.fallback != nil {
+		t.Errorf("expected the runtime map to be used for a struct key")
+	}
+	for x := 0; x < 50; x++ {
+		for y := 0; y < 50; y++ {
+			m.Put(point{x, y}, fmt.Sprint(x, ",", y))
+		}
+	}
+	m.Put(point{1, 2}, "replaced")
+	m.Delete(point{3, 4})
+
+	if n := m.Len(); n != 50*50-1 {
+		t.Errorf("expected %d entries, got %d", 50*50-1, n)
+	}
+	if v, ok := m.Get(point{1, 2}); !ok || v != "replaced" {
+		t.Errorf("unexpected value by {1, 2}: %q, %v", v, ok)
+	}
+	if v, ok := m.Get(point{2, 1}); !ok || v != "2,1" {
+		t.Errorf("unexpected value by {2, 1}: %q, %v", v, ok)
+	}
+	if v, ok := m.Get(point{3, 4}); ok || v != "" {
+		t.Errorf("deleted key returned %q", v)
+	}
+	if _, ok := m.Get(point{50, 0}); ok {
+		t.Errorf("missing key found")
+	}
+}
@@ -0,0 +1,85 @@
+package hashmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "=", `\=`)
+
+// WriteText writes the entries as key=value lines sorted by key.
+// Backslashes, newlines, carriage returns and '=' are escaped
+// with a backslash in both keys and values, so ReadText restores
+// the exact strings.
+func (m *StrMap) WriteText(w io.Writer) error {
+	entries := m.Entries()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		textEscaper.WriteString(bw, e.Key)
+		bw.WriteByte('=')
+		textEscaper.WriteString(bw, e.Value)
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadText puts the entries written by WriteText into the map.
+// Empty lines are skipped. On a malformed line it returns an error
+// wrapping ErrMalformedText, keeping the entries read before it.
+func (m *StrMap) ReadText(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			key, value, ok := parseTextLine(line)
+			if !ok {
+				return fmt.Errorf("line %d: %w", n, ErrMalformedText)
+			}
+			m.Put(key, value)
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// parseTextLine splits the line on the first unescaped '='
+// and unescapes both halves. Later unescaped '=' are kept in the value.
+func parseTextLine(line string) (key, value string, ok bool) {
+	var b strings.Builder
+	sawEq := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '=' && !sawEq:
+			key = b.String()
+			b.Reset()
+			sawEq = true
+			continue
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+		if i++; i == len(line) {
+			return "", "", false
+		}
+		switch line[i] {
+		case '\\', '=':
+			b.WriteByte(line[i])
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", "", false
+		}
+	}
+	return key, b.String(), sawEq
+}
@@ -0,0 +1,77 @@
+package hashmap
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrMapTextRoundTrip(t *testing.T) {
+	src := map[string]string{
+		"plain":      "value",
+		"a=b":        "c=d",
+		"multi\nkey": "line1\nline2\r\n",
+		`back\slash`: `\n is not a newline`,
+		"":           "empty key",
+		"empty":      "",
+		"=":          "=",
+	}
+	m, err := LoadStrMap(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := m.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText failed: %s", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(src) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(src), len(lines), buf.String())
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i-1] >= lines[i] {
+			t.Errorf("lines aren't sorted: %q before %q", lines[i-1], lines[i])
+		}
+	}
+
+	res := NewStrMap()
+	if err := res.ReadText(&buf); err != nil {
+		t.Fatalf("ReadText failed: %s", err)
+	}
+	if res.Len() != len(src) {
+		t.Errorf("expected %d entries, got %d", len(src), res.Len())
+	}
+	for k, v := range src {
+		if got, ok := res.GetPtrOk(k); !ok || *(*string)(got) != v {
+			t.Errorf("entry %q=%q didn't survive the round trip", k, v)
+		}
+	}
+}
+
+func TestStrMapTextEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewStrMap().WriteText(&buf); err != nil || buf.Len() != 0 {
+		t.Errorf("empty map wrote %q, %v", buf.String(), err)
+	}
+	m := NewStrMap()
+	if err := m.ReadText(strings.NewReader("")); err != nil || m.Len() != 0 {
+		t.Errorf("empty input read %d entries, %v", m.Len(), err)
+	}
+}
+
+func TestStrMapReadTextMalformed(t *testing.T) {
+	for _, in := range []string{"no separator\n", "a=b\\", "a=\\x\n"} {
+		err := NewStrMap().ReadText(strings.NewReader(in))
+		if !errors.Is(err, ErrMalformedText) {
+			t.Errorf("ReadText(%q) returned %v", in, err)
+		}
+	}
+	m := NewStrMap()
+	if err := m.ReadText(strings.NewReader("a=b=c\n\nlast=1")); err != nil {
+		t.Fatalf("ReadText failed: %s", err)
+	}
+	if v := m.GetOrDefault("a", ""); v != "b=c" || m.GetOrDefault("last", "") != "1" {
+		t.Errorf("unexpected entries: a=%q, last=%q", v, m.GetOrDefault("last", ""))
+	}
+}
@@ -0,0 +1,73 @@
+package hashmap
+
+import (
+	"unsafe" // #nosec
+
+	"github.com/gramework/runtimer"
+)
+
+// TypedMap is a type-safe view of a loaded map[K]V.
+// It uses the same fast access functions as Map.
+type TypedMap[K comparable, V any] struct {
+	hm  *hmap
+	typ *runtimer.MapType
+	fn  *mapFuncs
+}
+
+// NewTypedMap creates an empty TypedMap
+func NewTypedMap[K comparable, V any]() *TypedMap[K, V] {
+	mi := interface{}(map[K]V{})
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	typ := (*runtimer.MapType)(unsafe.Pointer(e.typ))
+	return &TypedMap[K, V]{
+		typ: typ,
+		hm:  makemap(typ, 0, nil, nil),
+		fn:  selectFuncs(typ),
+	}
+}
+
+// LoadMapTyped wraps m, which keeps sharing its data with the TypedMap.
+// It returns ErrNoData for a nil map.
+func LoadMapTyped[K comparable, V any](m map[K]V) (*TypedMap[K, V], error) {
+	if m == nil {
+		return nil, ErrNoData
+	}
+	mi := interface{}(m)
+	e := *(*emptyInterface)(unsafe.Pointer(&mi))
+	typ := (*runtimer.MapType)(unsafe.Pointer(e.typ))
+	return &TypedMap[K, V]{
+		typ: typ,
+		hm:  (*hmap)(e.word),
+		fn:  selectFuncs(typ),
+	}, nil
+}
+
+func (m *TypedMap[K, V]) Get(key K) (V, bool) {
+	p, ok := m.fn.access2(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return *(*V)(p), true
+}
+
+func (m *TypedMap[K, V]) Put(key K, value V) {
+	p := m.fn.assign(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+	*(*V)(p) = value
+}
+
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.fn.delete(m.typ, m.hm, runtimer.Noescape(unsafe.Pointer(&key)))
+}
+
+func (m *TypedMap[K, V]) Len() int {
+	return m.hm.len()
+}
+
+// Range calls fn for each entry until fn returns false.
+// Changes made during Range behave as with a built-in map.
+func (m *TypedMap[K, V]) Range(fn func(key K, value V) bool) {
+	mapiterate(m.typ, m.hm, func(k, v unsafe.Pointer) bool {
+		return fn(*(*K)(k), *(*V)(v))
+	})
+}
@@ -0,0 +1,80 @@
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLoadMapTypedStrInt(t *testing.T) {
+	src := map[string]int{"a": 1}
+	m, err := LoadMapTyped(src)
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(fmt.Sprint(i), i)
+	}
+	m.Delete("0")
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v", v, ok)
+	}
+	if v, ok := m.Get("42"); !ok || v != 42 {
+		t.Errorf("Get(42) = %d, %v", v, ok)
+	}
+	if _, ok := m.Get("0"); ok {
+		t.Errorf("deleted key was found")
+	}
+	if n := m.Len(); n != 100 {
+		t.Errorf("expected 100 entries, got %d", n)
+	}
+}
+
+func TestLoadMapTypedIntStr(t *testing.T) {
+	m, err := LoadMapTyped(map[int]string{})
+	if err != nil {
+		t.Fatalf("Can't load map: %s", err)
+	}
+	m.Put(-1, "minus one")
+	m.Put(1<<40, "big")
+	if v, ok := m.Get(1 << 40); !ok || v != "big" {
+		t.Errorf("Get(1<<40) = %q, %v", v, ok)
+	}
+	if v, ok := m.Get(2); ok || v != "" {
+		t.Errorf("Get of a missing key = %q, %v", v, ok)
+	}
+
+	if _, err := LoadMapTyped[int, string](nil); err != ErrNoData {
+		t.Errorf("expected ErrNoData, got %v", err)
+	}
+}
+
+func TestNewTypedMap(t *testing.T) {
+	type point struct{ X, Y int }
+	m := NewTypedMap[point, []string]()
+	if v, ok := m.Get(point{}); ok || v != nil {
+		t.Errorf("Get of a missing key = %v, %v", v, ok)
+	}
+	for i := 0; i < 100; i++ {
+		m.Put(point{i, i}, []string{fmt.Sprint(i)})
+	}
+	m.Delete(point{0, 0})
+	seen := 0
+	m.Range(func(k point, v []string) bool {
+		if k.X == 0 || len(v) != 1 || v[0] != fmt.Sprint(k.X) {
+			t.Errorf("unexpected entry %v: %v", k, v)
+		}
+		seen++
+		return true
+	})
+	if seen != 99 || m.Len() != 99 {
+		t.Errorf("expected 99 entries, visited %d, Len %d", seen, m.Len())
+	}
+	calls := 0
+	m.Range(func(point, []string) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Range didn't stop, %d calls", calls)
+	}
+}
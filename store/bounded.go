@@ -0,0 +1,51 @@
+package store
+
+import "container/list"
+
+// NewBounded creates a Store that holds at most max keys. Putting a new key
+// into a full Store evicts the oldest inserted key first. Replacing a key
+// doesn't change its position.
+func NewBounded(max int) *Store {
+	s := New()
+	s.max = max
+	s.fifo = list.New()
+	s.elems = make(map[string]*list.Element)
+	return s
+}
+
+// OnEvict sets a callback called with the key and the value
+// of each key evicted by a bounded Store. It's called without
+// holding the Store's lock, so it may use the Store.
+func (s *Store) OnEvict(fn func(key string, v interface{})) {
+	s.lock.Lock()
+	s.onEvict = fn
+	s.lock.Unlock()
+}
+
+// eviction is a key evicted by track, to pass to the OnEvict callback
+type eviction struct {
+	fn  func(key string, v interface{})
+	key string
+	v   interface{}
+}
+
+func (ev eviction) notify() {
+	if ev.fn != nil {
+		ev.fn(ev.key, ev.v)
+	}
+}
+
+// track records a new key, evicting the oldest one if the Store is full
+func (s *Store) track(key string) (ev eviction) {
+	if _, ok := s.elems[key]; ok {
+		return ev
+	}
+	if s.fifo.Len() >= s.max {
+		oldest := s.fifo.Front().Value.(string)
+		v, _ := s.get(oldest)
+		s.delete(oldest)
+		ev = eviction{fn: s.onEvict, key: oldest, v: v}
+	}
+	s.elems[key] = s.fifo.PushBack(key)
+	return ev
+}
@@ -0,0 +1,34 @@
+package store
+
+import "unsafe" // #nosec
+
+// MoveEntry moves the key with its value and ModTime from src to dst,
+// and reports whether src had it. Both Stores are locked during the move,
+// so no one sees the key in both or in neither.
+func MoveEntry(src, dst *Store, key string) bool {
+	if src == dst {
+		src.lock.Lock()
+		defer src.lock.Unlock()
+		_, ok := src.get(key)
+		return ok
+	}
+	// lock in address order, so concurrent moves in opposite
+	// directions can't deadlock
+	first, second := src, dst
+	if uintptr(unsafe.Pointer(dst)) < uintptr(unsafe.Pointer(src)) {
+		first, second = dst, src
+	}
+	first.lock.Lock()
+	second.lock.Lock()
+	v, ok := src.get(key)
+	var ev eviction
+	if ok {
+		modtime := src.modtimes[key]
+		src.delete(key)
+		ev = dst.put(key, v, modtime)
+	}
+	second.lock.Unlock()
+	first.lock.Unlock()
+	ev.notify()
+	return ok
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	s := New()
+	if _, ok := s.Get("key"); ok {
+		t.Errorf("a new Store has a key")
+	}
+	s.Put("key", 1)
+	if v, ok := s.Get("key"); !ok || v != 1 {
+		t.Errorf("unexpected value: %v, %v", v, ok)
+	}
+	s.Delete("key")
+	if _, ok := s.Get("key"); ok {
+		t.Errorf("deleted key is still stored")
+	}
+}
+
+func TestModTime(t *testing.T) {
+	s := New()
+	if _, ok := s.ModTime("key"); ok {
+		t.Errorf("unknown key has a ModTime")
+	}
+
+	s.Put("key", "first")
+	first, ok := s.ModTime("key")
+	if !ok {
+		t.Fatalf("no ModTime after Put")
+	}
+	time.Sleep(time.Millisecond)
+	s.Put("key", "second")
+	second, ok := s.ModTime("key")
+	if !ok {
+		t.Fatalf("no ModTime after second Put")
+	}
+	if !second.After(first) {
+		t.Errorf("ModTime didn't advance on re-Put: %v, then %v", first, second)
+	}
+	if _, ok := s.ModTime("other"); ok {
+		t.Errorf("unknown key has a ModTime")
+	}
+}
+
+func TestNewBounded(t *testing.T) {
+	const max = 3
+	s := NewBounded(max)
+	var evicted []string
+	s.OnEvict(func(key string, v interface{}) {
+		evicted = append(evicted, key)
+	})
+	for _, key := range []string{"a", "b", "c"} {
+		s.Put(key, 1)
+	}
+	s.Put("a", 2) // replacing doesn't refresh the position
+	if len(evicted) != 0 {
+		t.Fatalf("evicted %v before reaching max", evicted)
+	}
+	s.Put("d", 1)
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("expected a to be evicted, got %v", evicted)
+	}
+	if _, ok := s.Get("a"); ok {
+		t.Errorf("evicted key is still stored")
+	}
+	if _, ok := s.ModTime("a"); ok {
+		t.Errorf("evicted key still has a ModTime")
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, ok := s.Get(key); !ok {
+			t.Errorf("key %s is missing", key)
+		}
+	}
+
+	s.Delete("c")
+	s.Put("e", 1)
+	if len(evicted) != 1 {
+		t.Errorf("Put after Delete evicted %v", evicted[1:])
+	}
+	s.Put("f", 1)
+	if len(evicted) != 2 || evicted[1] != "b" {
+		t.Errorf("expected b to be evicted, got %v", evicted)
+	}
+}
+
+func TestGetValue(t *testing.T) {
+	type point struct {
+		X, Y int
+		Name string
+	}
+	s := New()
+	s.Put("p", point{1, 2, "a"})
+	s.Put("ptr", &point{3, 4, "b"})
+	s.Put("n", 42)
+	var empty error
+	s.Put("nil", empty)
+
+	if v, ok := s.Get("p"); !ok || v != (point{1, 2, "a"}) {
+		t.Errorf("expected the struct back, got %#v, %v", v, ok)
+	}
+	if v, ok := s.Get("ptr"); !ok || *v.(*point) != (point{3, 4, "b"}) {
+		t.Errorf("expected the pointer back, got %#v, %v", v, ok)
+	}
+	if v, ok := s.Get("n"); !ok || v != 42 {
+		t.Errorf("expected 42, got %#v, %v", v, ok)
+	}
+	if v, ok := s.Get("nil"); !ok || v != nil {
+		t.Errorf("expected a stored nil, got %#v, %v", v, ok)
+	}
+	if v, ok := s.Get("missing"); ok || v != nil {
+		t.Errorf("missing key returned %#v, %v", v, ok)
+	}
+}
+
+func TestMoveEntry(t *testing.T) {
+	a, b := New(), New()
+	a.Put("key", "value")
+	mod, _ := a.ModTime("key")
+	if !MoveEntry(a, b, "key") {
+		t.Fatalf("MoveEntry didn't find the key")
+	}
+	if _, ok := a.Get("key"); ok {
+		t.Errorf("the key is still in src")
+	}
+	if v, ok := b.Get("key"); !ok || v != "value" {
+		t.Errorf("unexpected value in dst: %v, %v", v, ok)
+	}
+	if m, ok := b.ModTime("key"); !ok || !m.Equal(mod) {
+		t.Errorf("ModTime wasn't moved: %v, expected %v", m, mod)
+	}
+	if MoveEntry(a, b, "key") || MoveEntry(a, b, "missing") {
+		t.Errorf("MoveEntry moved a missing key")
+	}
+	if !MoveEntry(b, b, "key") {
+		t.Errorf("MoveEntry to the same Store didn't find the key")
+	}
+}
+
+func TestMoveEntryConcurrent(t *testing.T) {
+	const keys = 100
+	a, b := New(), New()
+	for i := 0; i < keys; i++ {
+		a.Put(fmt.Sprint(i), i)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprint((w + i) % keys)
+				if w%2 == 0 {
+					MoveEntry(a, b, key)
+				} else {
+					MoveEntry(b, a, key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for i := 0; i < keys; i++ {
+		key := fmt.Sprint(i)
+		va, inA := a.Get(key)
+		vb, inB := b.Get(key)
+		if inA == inB {
+			t.Errorf("key %s: in a is %v, in b is %v", key, inA, inB)
+		}
+		v := va
+		if inB {
+			v = vb
+		}
+		if v != i {
+			t.Errorf("key %s has value %v", key, v)
+		}
+	}
+}
@@ -1,24 +1,95 @@
 package store
 
 import (
+	"container/list"
+	"sync"
+	"time"
+
 	"github.com/gramework/threadsafe/hashmap"
 	"github.com/gramework/utils/nocopy"
 )
 
-// Store itself
+// Store itself. It's safe for concurrent use.
 type Store struct {
-	store hashmap.Map
+	lock     sync.Mutex
+	store    hashmap.Map
+	modtimes map[string]time.Time
+
+	// FIFO eviction, see NewBounded
+	max     int
+	fifo    *list.List // of keys, the oldest first
+	elems   map[string]*list.Element
+	onEvict func(key string, v interface{})
 
 	nocopy nocopy.NoCopy
 }
 
+// New creates an empty Store. The zero Store isn't usable.
+func New() *Store {
+	m, err := hashmap.LoadMap(map[string]interface{}{})
+	if err != nil {
+		panic(err) // a non-nil map always loads
+	}
+	return &Store{
+		store:    *m,
+		modtimes: make(map[string]time.Time),
+	}
+}
+
 // Put or replace a key
 func (s *Store) Put(key string, v interface{}) {
+	s.lock.Lock()
+	ev := s.put(key, v, time.Now())
+	s.lock.Unlock()
+	ev.notify()
+}
+
+// put expects the lock to be held. The returned eviction
+// must be notified after releasing it.
+func (s *Store) put(key string, v interface{}, modtime time.Time) (ev eviction) {
+	if s.max > 0 {
+		ev = s.track(key)
+	}
 	s.store.Put(key, v)
+	s.modtimes[key] = modtime
+	return ev
 }
 
 // Get a key from the storage
 func (s *Store) Get(key string) (v interface{}, ok bool) {
-	v, ok = s.store.GetPtrOk(key)
-	return
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.get(key)
+}
+
+func (s *Store) get(key string) (v interface{}, ok bool) {
+	p, ok := hashmap.Get[interface{}](&s.store, key)
+	if !ok {
+		return nil, false
+	}
+	return *p, true
+}
+
+// Delete a key from the storage
+func (s *Store) Delete(key string) {
+	s.lock.Lock()
+	s.delete(key)
+	s.lock.Unlock()
+}
+
+func (s *Store) delete(key string) {
+	s.store.Delete(key)
+	delete(s.modtimes, key)
+	if e, ok := s.elems[key]; ok {
+		s.fifo.Remove(e)
+		delete(s.elems, key)
+	}
+}
+
+// ModTime returns the time the key was last Put
+func (s *Store) ModTime(key string) (time.Time, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	t, ok := s.modtimes[key]
+	return t, ok
 }